@@ -0,0 +1,325 @@
+package pluginfx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// ErrToolchainMismatch indicates that the go command available to build a plugin is not
+// the same version as the toolchain that built the host program.
+var ErrToolchainMismatch = errors.New("The go command does not match the host's Go version")
+
+// ErrBuildSource indicates that Build was set for a plugin read from an fs.FS or a URL,
+// neither of which can be built.
+var ErrBuildSource = errors.New("Only a local package directory can be built, not a file in an fs.FS or a URL")
+
+// BuildError indicates that a plugin could not be built from source.
+type BuildError struct {
+	// Dir is the package directory that was built.
+	Dir string
+
+	// Output is the combined output of the go command that failed, if any.
+	Output string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (be *BuildError) Unwrap() error {
+	return be.Err
+}
+
+func (be *BuildError) Error() string {
+	if len(be.Output) > 0 {
+		return fmt.Sprintf("Unable to build plugin from %s: %s\n%s", be.Dir, be.Err, be.Output)
+	}
+
+	return fmt.Sprintf("Unable to build plugin from %s: %s", be.Dir, be.Err)
+}
+
+// goEnv is the subset of go env that determines how a plugin is built.
+type goEnv struct {
+	GOVERSION  string
+	GOOS       string
+	GOARCH     string
+	CGOEnabled string `json:"CGO_ENABLED"`
+	GOFLAGS    string
+	GOMOD      string
+}
+
+// listedPackage is the subset of go list -json output that determines a plugin's content.
+type listedPackage struct {
+	Dir      string
+	Standard bool
+	Module   *listedModule
+}
+
+type listedModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Replace *listedModule
+}
+
+// local tests if a package's source may change without its module version changing,
+// i.e. it is not in the module cache.
+func (lp listedPackage) local() bool {
+	m := lp.Module
+	if m == nil || m.Main {
+		return true
+	}
+
+	return m.Replace != nil && len(m.Replace.Version) == 0
+}
+
+// hostToolchain returns the GOTOOLCHAIN value that selects the host's Go version.  For
+// development builds, which cannot be selected, the empty string is returned.
+func hostToolchain() string {
+	v := runtime.Version()
+	if !strings.HasPrefix(v, "go1") || strings.ContainsAny(v, " \t") {
+		return ""
+	}
+
+	return v
+}
+
+// hostBuildFlags returns the go build flags that the host program was built with and
+// that a plugin must also be built with in order to be opened.
+func hostBuildFlags() (flags []string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "-race" && s.Value == "true":
+			flags = append(flags, "-race")
+
+		case s.Key == "-trimpath" && s.Value == "true":
+			flags = append(flags, "-trimpath")
+
+		case s.Key == "-tags" && len(s.Value) > 0:
+			flags = append(flags, "-tags="+s.Value)
+		}
+	}
+
+	return
+}
+
+// builder builds plugins from Go package directories into a cache directory.
+type builder struct {
+	dir   string
+	flags []string
+}
+
+// newBuilder creates a builder for the given optional cache directory.
+func newBuilder(dir string) builder {
+	if len(dir) == 0 {
+		dir = DefaultCacheDir()
+	}
+
+	return builder{
+		dir:   dir,
+		flags: hostBuildFlags(),
+	}
+}
+
+// command creates a go command that runs in a package directory with the host's toolchain.
+func (b builder) command(source string, args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = source
+	cmd.Env = os.Environ()
+	if tc := hostToolchain(); len(tc) > 0 {
+		cmd.Env = append(cmd.Env, "GOTOOLCHAIN="+tc)
+	}
+
+	return cmd
+}
+
+// run runs a go command, returning its standard output.
+func (b builder) run(source string, args ...string) ([]byte, error) {
+	var (
+		stdout, stderr bytes.Buffer
+		cmd            = b.command(source, args...)
+	)
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, &BuildError{
+			Dir:    source,
+			Output: stderr.String(),
+			Err:    err,
+		}
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// key computes the cache key for a package directory.  The key covers the toolchain and its
+// settings, the build flags, the module graph, and the content of every dependency that is not
+// in the module cache, which includes the package itself.
+func (b builder) key(source string) (string, error) {
+	var env goEnv
+	output, err := b.run(source, "env", "-json", "GOVERSION", "GOOS", "GOARCH", "CGO_ENABLED", "GOFLAGS", "GOMOD")
+	if err == nil {
+		err = json.Unmarshal(output, &env)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if env.GOVERSION != runtime.Version() {
+		return "", &BuildError{
+			Dir: source,
+			Err: fmt.Errorf("%w: go command is %s, host is %s", ErrToolchainMismatch, env.GOVERSION, runtime.Version()),
+		}
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "toolchain %s %s/%s cgo=%s goflags=%q flags=%q\n", env.GOVERSION, env.GOOS, env.GOARCH, env.CGOEnabled, env.GOFLAGS, b.flags)
+	if len(env.GOMOD) > 0 && env.GOMOD != os.DevNull {
+		for _, f := range []string{env.GOMOD, filepath.Join(filepath.Dir(env.GOMOD), "go.sum")} {
+			if err := hashFile(h, f); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", &BuildError{Dir: source, Err: err}
+			}
+		}
+	}
+
+	output, err = b.run(source, "list", "-deps", "-json", ".")
+	if err != nil {
+		return "", err
+	}
+
+	for d := json.NewDecoder(bytes.NewReader(output)); d.More(); {
+		var lp listedPackage
+		if err := d.Decode(&lp); err != nil {
+			return "", &BuildError{Dir: source, Err: err}
+		}
+
+		switch {
+		case lp.Standard:
+			// covered by the toolchain version
+
+		case lp.local():
+			if err := hashDir(h, lp.Dir); err != nil {
+				return "", &BuildError{Dir: source, Err: err}
+			}
+
+		default:
+			fmt.Fprintf(h, "module %s@%s", lp.Module.Path, lp.Module.Version)
+			if r := lp.Module.Replace; r != nil {
+				fmt.Fprintf(h, " => %s@%s", r.Path, r.Version)
+			}
+
+			fmt.Fprintln(h)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile writes a file's name and content to h.
+func hashFile(h io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	fmt.Fprintf(h, "file %s\n", path)
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// hashDir writes each regular file directly within a directory to h, in name order.
+func hashDir(h io.Writer, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if err := hashFile(h, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// build returns the path of the plugin built from a package directory, building it if there
+// is no cached artifact for the same source, toolchain, and module graph.
+func (b builder) build(source string) (*Built, error) {
+	source = absolute(source)
+	if fi, err := os.Stat(source); err != nil {
+		return nil, &BuildError{Dir: source, Err: err}
+	} else if !fi.IsDir() {
+		return nil, &BuildError{Dir: source, Err: fmt.Errorf("%s is not a package directory", source)}
+	}
+
+	key, err := b.key(source)
+	if err != nil {
+		return nil, err
+	}
+
+	target := filepath.Join(b.dir, key+".so")
+	if _, err := os.Stat(target); err == nil {
+		return &Built{Source: source, Path: target, Cached: true}, nil
+	}
+
+	if err := os.MkdirAll(b.dir, 0700); err != nil {
+		return nil, &BuildError{Dir: source, Err: err}
+	}
+
+	// build into a temporary file first, so that a partially written plugin is never reused
+	f, err := os.CreateTemp(b.dir, ".pluginfx-*.so")
+	if err != nil {
+		return nil, &BuildError{Dir: source, Err: err}
+	}
+
+	f.Close()
+	defer os.Remove(f.Name())
+
+	args := append([]string{"build", "-buildmode=plugin", "-o", f.Name()}, b.flags...)
+	cmd := b.command(source, append(args, ".")...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, &BuildError{
+			Dir:    source,
+			Output: string(output),
+			Err:    err,
+		}
+	}
+
+	if err := os.Chmod(f.Name(), 0500); err != nil {
+		return nil, &BuildError{Dir: source, Err: err}
+	}
+
+	if err := os.Rename(f.Name(), target); err != nil {
+		return nil, &BuildError{Dir: source, Err: err}
+	}
+
+	return &Built{Source: source, Path: target}, nil
+}
@@ -0,0 +1,202 @@
+package pluginfx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+const (
+	builtSource  = "testdata/built"
+	brokenSource = "testdata/broken"
+)
+
+type BuildSuite struct {
+	PluginfxSuite
+}
+
+// writeModule creates a standalone module containing a plugin package, returning its directory.
+func (suite *BuildSuite) writeModule(source string) string {
+	dir := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/plugin\n\ngo 1.18\n"), 0600))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0600))
+	return dir
+}
+
+func (suite *BuildSuite) TestBuild() {
+	var (
+		dir = suite.T().TempDir()
+		b   = newBuilder(dir)
+	)
+
+	first, err := b.build(builtSource)
+	suite.Require().NoError(err)
+	suite.False(first.Cached)
+	suite.Equal(absolute(builtSource), first.Source)
+	suite.Equal(dir, filepath.Dir(first.Path))
+	suite.FileExists(first.Path)
+
+	second, err := b.build(builtSource)
+	suite.Require().NoError(err)
+	suite.True(second.Cached)
+	suite.Equal(first.Path, second.Path)
+
+	entries, err := os.ReadDir(dir)
+	suite.NoError(err)
+	suite.Len(entries, 1)
+}
+
+func (suite *BuildSuite) TestKey() {
+	var (
+		b      = newBuilder(suite.T().TempDir())
+		source = suite.writeModule("package main\n\nfunc Name() string { return \"one\" }\n\nfunc main() {}\n")
+	)
+
+	key, err := b.key(source)
+	suite.Require().NoError(err)
+	suite.Len(key, 64)
+
+	same, err := b.key(source)
+	suite.Require().NoError(err)
+	suite.Equal(key, same)
+
+	suite.Run("SourceChanged", func() {
+		suite.Require().NoError(os.WriteFile(filepath.Join(source, "main.go"), []byte("package main\n\nfunc Name() string { return \"two\" }\n\nfunc main() {}\n"), 0600))
+		changed, err := b.key(source)
+		suite.Require().NoError(err)
+		suite.NotEqual(key, changed)
+		key = changed
+	})
+
+	suite.Run("ModuleChanged", func() {
+		suite.Require().NoError(os.WriteFile(filepath.Join(source, "go.mod"), []byte("module example.com/plugin\n\ngo 1.19\n"), 0600))
+		changed, err := b.key(source)
+		suite.Require().NoError(err)
+		suite.NotEqual(key, changed)
+		key = changed
+	})
+
+	suite.Run("FlagsChanged", func() {
+		withFlags := b
+		withFlags.flags = append([]string{"-trimpath"}, b.flags...)
+		changed, err := withFlags.key(source)
+		suite.Require().NoError(err)
+		suite.NotEqual(key, changed)
+	})
+}
+
+func (suite *BuildSuite) TestBuildError() {
+	b := newBuilder(suite.T().TempDir())
+
+	suite.Run("CompileError", func() {
+		built, err := b.build(brokenSource)
+		suite.Nil(built)
+
+		var be *BuildError
+		suite.Require().True(errors.As(err, &be))
+		suite.Equal(absolute(brokenSource), be.Dir)
+		suite.Contains(be.Output, "undefined")
+		suite.Error(be.Err)
+		suite.Contains(be.Error(), be.Output)
+	})
+
+	suite.Run("NotADirectory", func() {
+		_, err := b.build("build.go")
+
+		var be *BuildError
+		suite.Require().True(errors.As(err, &be))
+		suite.Empty(be.Output)
+	})
+
+	suite.Run("Missing", func() {
+		_, err := b.build("testdata/nosuch")
+		suite.ErrorIs(err, os.ErrNotExist)
+	})
+}
+
+func (suite *BuildSuite) TestP() {
+	var (
+		recorder eventRecorder
+		name     string
+		registry *Registry
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Anonymous: true,
+				Path:      builtSource,
+				Build:     true,
+				CacheDir:  testCacheDir,
+				Logger:    &recorder,
+				Symbols: Symbols{
+					Names: []interface{}{"Name"},
+				},
+			}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&name, &registry),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Equal("built", name)
+
+	suite.Require().NotEmpty(recorder.events)
+	built, ok := recorder.events[0].(*Built)
+	suite.Require().True(ok)
+	suite.Equal(absolute(builtSource), built.Source)
+	suite.Equal(testCacheDir, filepath.Dir(built.Path))
+
+	record, ok := registry.Get(builtSource)
+	suite.Require().True(ok)
+	suite.Equal(built.Path, record.ExpandedPath)
+
+	suite.Run("BuildError", func() {
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      brokenSource,
+				Build:     true,
+				CacheDir:  suite.T().TempDir(),
+			}.Provide(),
+		)
+
+		var be *BuildError
+		suite.Require().True(errors.As(app.Err(), &be))
+
+		var pe *PluginError
+		suite.Require().True(errors.As(app.Err(), &pe))
+		suite.Equal(brokenSource, pe.Path)
+		suite.Equal(PhaseOpen, pe.Phase)
+	})
+
+	suite.Run("Source", func() {
+		for _, p := range []P{
+			{Path: "sample", FS: fstest.MapFS{"sample/sample.go": &fstest.MapFile{}}},
+			{Path: "http://example.com/sample"},
+			{Path: builtSource, Fallbacks: []string{"https://example.com/sample"}},
+		} {
+			p.Anonymous = true
+			p.Build = true
+			p.CacheDir = suite.T().TempDir()
+
+			app := fx.New(p.Provide())
+			suite.ErrorIs(app.Err(), ErrBuildSource)
+
+			var pe *PluginError
+			suite.Require().True(errors.As(app.Err(), &pe))
+			suite.Equal(p.Path, pe.Path)
+			suite.Equal(PhaseOpen, pe.Phase)
+		}
+	})
+}
+
+func TestBuild(t *testing.T) {
+	suite.Run(t, new(BuildSuite))
+}
@@ -0,0 +1,195 @@
+// Command pluginfx is a diagnostic tool for Go plugins intended for use with
+// github.com/xmidt-org/pluginfx.
+//
+// Usage:
+//
+//   pluginfx inspect [-json] path.so [symbol ...]
+//
+// The inspect command opens the plugin, without starting an fx.App, and reports
+// whether each named symbol exists, its Go type, and how pluginfx would treat it.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xmidt-org/pluginfx"
+)
+
+const (
+	exitSuccess = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// symbolReport is the JSON form of a pluginfx.SymbolInfo.
+type symbolReport struct {
+	Name      string `json:"name"`
+	Found     bool   `json:"found"`
+	Type      string `json:"type,omitempty"`
+	Function  bool   `json:"function"`
+	Kind      string `json:"kind,omitempty"`
+	Target    bool   `json:"target"`
+	Lifecycle bool   `json:"lifecycle"`
+}
+
+// report is the JSON output of the inspect command.
+type report struct {
+	Path    string         `json:"path"`
+	Opened  bool           `json:"opened"`
+	Error   string         `json:"error,omitempty"`
+	Cause   string         `json:"cause,omitempty"`
+	Hint    string         `json:"hint,omitempty"`
+	Symbols []symbolReport `json:"symbols"`
+}
+
+func newReport(path string, infos []pluginfx.SymbolInfo, err error) report {
+	r := report{
+		Path:    path,
+		Opened:  err == nil,
+		Symbols: make([]symbolReport, 0, len(infos)),
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	var oe *pluginfx.OpenError
+	if errors.As(err, &oe) {
+		r.Cause = string(oe.Cause)
+		r.Hint = oe.Remediation()
+	}
+
+	for _, info := range infos {
+		sr := symbolReport{
+			Name:      info.Name,
+			Found:     info.Found(),
+			Function:  info.Function,
+			Target:    info.Target,
+			Lifecycle: info.Lifecycle,
+		}
+
+		if info.Type != nil {
+			sr.Type = info.Type.String()
+		}
+
+		if info.Function {
+			sr.Kind = strings.ToLower(info.Kind.String())
+		}
+
+		r.Symbols = append(r.Symbols, sr)
+	}
+
+	return r
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
+
+func writeText(w io.Writer, r report) {
+	if !r.Opened {
+		fmt.Fprintf(w, "open %s: FAILED: %s\n", r.Path, r.Error)
+		if len(r.Hint) > 0 {
+			fmt.Fprintf(w, "hint: %s\n", r.Hint)
+		}
+
+		return
+	}
+
+	fmt.Fprintf(w, "open %s: OK\n", r.Path)
+	if len(r.Symbols) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SYMBOL\tFOUND\tTYPE\tKIND\tTARGET\tLIFECYCLE")
+	for _, s := range r.Symbols {
+		kind := s.Kind
+		if len(kind) == 0 {
+			kind = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, yesNo(s.Found), s.Type, kind, yesNo(s.Target), yesNo(s.Lifecycle),
+		)
+	}
+
+	tw.Flush()
+}
+
+func inspect(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: pluginfx inspect [-json] path.so [symbol ...]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var (
+		path  = fs.Arg(0)
+		infos []pluginfx.SymbolInfo
+	)
+
+	p, err := pluginfx.Open(path)
+	if err == nil {
+		infos = pluginfx.Inspect(p, fs.Args()[1:]...)
+	}
+
+	r := newReport(path, infos, err)
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(r)
+	} else {
+		writeText(stdout, r)
+	}
+
+	if err != nil {
+		return exitFailure
+	}
+
+	return exitSuccess
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprintln(stderr, "Usage: pluginfx <command> [arguments]")
+		fmt.Fprintln(stderr, "Commands:")
+		fmt.Fprintln(stderr, "  inspect    report on the symbols exported by a plugin")
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "inspect":
+		return inspect(args[1:], stdout, stderr)
+
+	default:
+		fmt.Fprintln(stderr, errors.New("unknown command: "+args[0]))
+		return exitUsage
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/pluginfx"
+)
+
+type MainSuite struct {
+	suite.Suite
+}
+
+func (suite *MainSuite) run(args ...string) (code int, stdout, stderr string) {
+	var out, err bytes.Buffer
+	code = run(args, &out, &err)
+	stdout, stderr = out.String(), err.String()
+	return
+}
+
+func (suite *MainSuite) TestUsage() {
+	code, _, stderr := suite.run()
+	suite.Equal(exitUsage, code)
+	suite.Contains(stderr, "Usage")
+
+	code, _, stderr = suite.run("nosuch")
+	suite.Equal(exitUsage, code)
+	suite.Contains(stderr, "unknown command")
+
+	code, _, stderr = suite.run("inspect")
+	suite.Equal(exitUsage, code)
+	suite.Contains(stderr, "Usage")
+
+	code, _, _ = suite.run("inspect", "-nosuch")
+	suite.Equal(exitUsage, code)
+}
+
+func (suite *MainSuite) TestOpenFailure() {
+	code, stdout, _ := suite.run("inspect", "/no/such/plugin.so", "New")
+	suite.Equal(exitFailure, code)
+	suite.Contains(stdout, "FAILED")
+	suite.Contains(stdout, "hint: ")
+
+	code, stdout, _ = suite.run("inspect", "-json", "/no/such/plugin.so", "New")
+	suite.Equal(exitFailure, code)
+
+	var r report
+	suite.Require().NoError(json.Unmarshal([]byte(stdout), &r))
+	suite.False(r.Opened)
+	suite.NotEmpty(r.Error)
+	suite.Equal(string(pluginfx.CauseNotFound), r.Cause)
+	suite.NotEmpty(r.Hint)
+	suite.Empty(r.Symbols)
+}
+
+func (suite *MainSuite) TestReport() {
+	var (
+		infos = pluginfx.Inspect(
+			pluginfx.NewSymbols(
+				"New", func() (int, error) { return 0, nil },
+				"Value", 12,
+			),
+			"New", "Value", "Missing",
+		)
+
+		r = newReport("test.so", infos, nil)
+	)
+
+	suite.True(r.Opened)
+	suite.Equal(
+		[]symbolReport{
+			{Name: "New", Found: true, Type: reflect.TypeOf(func() (int, error) { return 0, nil }).String(), Function: true, Kind: "provide", Target: true},
+			{Name: "Value", Found: true, Type: "*int"},
+			{Name: "Missing"},
+		},
+		r.Symbols,
+	)
+
+	var output bytes.Buffer
+	writeText(&output, r)
+	suite.Contains(output.String(), "open test.so: OK")
+	suite.Contains(output.String(), "Missing")
+
+	output.Reset()
+	writeText(&output, newReport("test.so", nil, errors.New("expected")))
+	suite.Contains(output.String(), "FAILED: expected")
+}
+
+func TestCommand(t *testing.T) {
+	suite.Run(t, new(MainSuite))
+}
@@ -0,0 +1,206 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Condition decides whether a plugin is loaded at all.  Conditions are evaluated
+// by P.Provide and S.Provide, before any path expansion or opening takes place.
+//
+// Conditions should implement fmt.Stringer, as the string form is what gets reported
+// when a plugin is disabled.  All the conditions created by this package do.
+type Condition interface {
+	// Enabled tests if the plugin should be loaded.  An error shortcircuits application
+	// startup, or skips the plugin if it is optional.
+	Enabled() (bool, error)
+}
+
+// ConditionFunc is a function type that implements Condition.
+type ConditionFunc func() (bool, error)
+
+// Enabled invokes this function.
+func (cf ConditionFunc) Enabled() (bool, error) {
+	return cf()
+}
+
+// String returns a generic description of a custom condition.
+func (cf ConditionFunc) String() string {
+	return "custom"
+}
+
+// condition is the internal Condition implementation that carries a description.
+type condition struct {
+	desc    string
+	enabled func() (bool, error)
+}
+
+func (c condition) Enabled() (bool, error) {
+	return c.enabled()
+}
+
+func (c condition) String() string {
+	return c.desc
+}
+
+// newCondition creates a described Condition from a simple predicate.
+func newCondition(desc string, f func() bool) Condition {
+	return condition{
+		desc: desc,
+		enabled: func() (bool, error) {
+			return f(), nil
+		},
+	}
+}
+
+// describe returns the string form of a Condition.
+func describe(c Condition) string {
+	if s, ok := c.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return fmt.Sprintf("%T", c)
+}
+
+// EnvPresent is a Condition that enables a plugin when the given environment variable is
+// defined, even if it is empty.
+func EnvPresent(name string) Condition {
+	return newCondition(
+		fmt.Sprintf("env %s present", name),
+		func() bool {
+			_, ok := os.LookupEnv(name)
+			return ok
+		},
+	)
+}
+
+// EnvEquals is a Condition that enables a plugin when the given environment variable is
+// defined and has exactly the given value.
+func EnvEquals(name, value string) Condition {
+	return newCondition(
+		fmt.Sprintf("env %s=%s", name, value),
+		func() bool {
+			v, ok := os.LookupEnv(name)
+			return ok && v == value
+		},
+	)
+}
+
+// oneOf tests if actual is one of the allowed values.
+func oneOf(actual string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == actual {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GOOS is a Condition that enables a plugin when runtime.GOOS is one of the given values.
+func GOOS(values ...string) Condition {
+	return newCondition(
+		fmt.Sprintf("GOOS in [%s]", strings.Join(values, ",")),
+		func() bool {
+			return oneOf(runtime.GOOS, values)
+		},
+	)
+}
+
+// GOARCH is a Condition that enables a plugin when runtime.GOARCH is one of the given values.
+func GOARCH(values ...string) Condition {
+	return newCondition(
+		fmt.Sprintf("GOARCH in [%s]", strings.Join(values, ",")),
+		func() bool {
+			return oneOf(runtime.GOARCH, values)
+		},
+	)
+}
+
+// FileExists is a Condition that enables a plugin when the given file or directory exists.
+// Any error from os.Stat other than the file not existing is returned from Enabled.
+func FileExists(path string) Condition {
+	return condition{
+		desc: fmt.Sprintf("file %s exists", path),
+		enabled: func() (bool, error) {
+			_, err := os.Stat(path)
+			switch {
+			case err == nil:
+				return true, nil
+
+			case errors.Is(err, os.ErrNotExist):
+				return false, nil
+
+			default:
+				return false, err
+			}
+		},
+	}
+}
+
+// Not is a Condition that negates another Condition.
+func Not(c Condition) Condition {
+	return condition{
+		desc: fmt.Sprintf("not (%s)", describe(c)),
+		enabled: func() (bool, error) {
+			enabled, err := c.Enabled()
+			return !enabled && err == nil, err
+		},
+	}
+}
+
+// All is a Condition that enables a plugin only if all of the given conditions do.
+// Conditions are evaluated in order, stopping at the first one that is not enabled.
+func All(conditions ...Condition) Condition {
+	descs := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		descs = append(descs, describe(c))
+	}
+
+	return condition{
+		desc: strings.Join(descs, " and "),
+		enabled: func() (bool, error) {
+			for _, c := range conditions {
+				if enabled, err := c.Enabled(); !enabled || err != nil {
+					return false, err
+				}
+			}
+
+			return true, nil
+		},
+	}
+}
+
+// Any is a Condition that enables a plugin if at least one of the given conditions does.
+// Conditions are evaluated in order, stopping at the first one that is enabled.
+func Any(conditions ...Condition) Condition {
+	descs := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		descs = append(descs, describe(c))
+	}
+
+	return condition{
+		desc: strings.Join(descs, " or "),
+		enabled: func() (bool, error) {
+			for _, c := range conditions {
+				if enabled, err := c.Enabled(); enabled || err != nil {
+					return enabled, err
+				}
+			}
+
+			return false, nil
+		},
+	}
+}
+
+// checkEnabled evaluates an optional Condition.  A nil Condition is always enabled.
+func checkEnabled(c Condition) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+
+	return c.Enabled()
+}
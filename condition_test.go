@@ -0,0 +1,229 @@
+package pluginfx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type ConditionSuite struct {
+	PluginfxSuite
+}
+
+func (suite *ConditionSuite) assertEnabled(expected bool, c Condition) {
+	enabled, err := c.Enabled()
+	suite.NoError(err)
+	suite.Equal(expected, enabled, "%s", describe(c))
+}
+
+func (suite *ConditionSuite) TestEnv() {
+	suite.T().Setenv("PLUGINFX_TEST_PRESENT", "")
+	suite.T().Setenv("PLUGINFX_TEST_EQUALS", "prod")
+
+	suite.assertEnabled(true, EnvPresent("PLUGINFX_TEST_PRESENT"))
+	suite.assertEnabled(false, EnvPresent("PLUGINFX_TEST_NOSUCH"))
+	suite.assertEnabled(true, EnvEquals("PLUGINFX_TEST_EQUALS", "prod"))
+	suite.assertEnabled(false, EnvEquals("PLUGINFX_TEST_EQUALS", "dev"))
+	suite.assertEnabled(false, EnvEquals("PLUGINFX_TEST_NOSUCH", ""))
+
+	suite.Equal("env PLUGINFX_TEST_PRESENT present", describe(EnvPresent("PLUGINFX_TEST_PRESENT")))
+	suite.Equal("env PLUGINFX_TEST_EQUALS=prod", describe(EnvEquals("PLUGINFX_TEST_EQUALS", "prod")))
+}
+
+func (suite *ConditionSuite) TestPlatform() {
+	suite.assertEnabled(true, GOOS("plan9", runtime.GOOS))
+	suite.assertEnabled(false, GOOS("nosuch"))
+	suite.assertEnabled(true, GOARCH(runtime.GOARCH))
+	suite.assertEnabled(false, GOARCH("nosuch", "alsonosuch"))
+	suite.Equal("GOOS in [linux,darwin]", describe(GOOS("linux", "darwin")))
+}
+
+func (suite *ConditionSuite) TestFileExists() {
+	dir := suite.T().TempDir()
+	suite.assertEnabled(true, FileExists(dir))
+	suite.assertEnabled(false, FileExists(filepath.Join(dir, "nosuch")))
+
+	file := filepath.Join(dir, "file")
+	suite.Require().NoError(os.WriteFile(file, nil, 0600))
+	suite.assertEnabled(true, FileExists(file))
+
+	// stat'ing through a regular file is an error other than not existing
+	enabled, err := FileExists(filepath.Join(file, "child")).Enabled()
+	suite.False(enabled)
+	suite.Error(err)
+}
+
+func (suite *ConditionSuite) TestCombinators() {
+	var (
+		yes    = ConditionFunc(func() (bool, error) { return true, nil })
+		no     = ConditionFunc(func() (bool, error) { return false, nil })
+		broken = ConditionFunc(func() (bool, error) { return false, errors.New("expected") })
+	)
+
+	suite.Equal("custom", describe(yes))
+	suite.assertEnabled(false, Not(yes))
+	suite.assertEnabled(true, Not(no))
+	suite.Equal("not (custom)", describe(Not(yes)))
+
+	suite.assertEnabled(true, All())
+	suite.assertEnabled(true, All(yes, yes))
+	suite.assertEnabled(false, All(yes, no, broken))
+	suite.Equal("custom and custom", describe(All(yes, no)))
+
+	suite.assertEnabled(false, Any())
+	suite.assertEnabled(true, Any(no, yes, broken))
+	suite.assertEnabled(false, Any(no, no))
+	suite.Equal("custom or custom", describe(Any(yes, no)))
+
+	for _, c := range []Condition{Not(broken), All(yes, broken), Any(no, broken)} {
+		enabled, err := c.Enabled()
+		suite.False(enabled)
+		suite.Error(err)
+	}
+}
+
+func (suite *ConditionSuite) TestPDisabled() {
+	var (
+		recorder eventRecorder
+		registry *Registry
+		op       OptionalPlugin
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Name:     "sample",
+				Path:     "$PLUGINFX_TEST_NOSUCH/sample.so",
+				Expander: ShellExpander{Strict: true},
+				Enabled:  EnvPresent("PLUGINFX_TEST_NOSUCH"),
+				Optional: true,
+				Logger:   &recorder,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&registry),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					OP OptionalPlugin `name:"sample"`
+				}) {
+					op = in.OP
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.False(op.Present())
+	suite.NoError(op.Err)
+	suite.Equal(
+		[]Event{&Disabled{Path: "$PLUGINFX_TEST_NOSUCH/sample.so", Condition: "env PLUGINFX_TEST_NOSUCH present"}},
+		recorder.events,
+	)
+
+	record, ok := registry.Get("$PLUGINFX_TEST_NOSUCH/sample.so")
+	suite.Require().True(ok)
+	suite.Equal(StatusDisabled, record.Status)
+	suite.Equal("env PLUGINFX_TEST_NOSUCH present", record.Condition)
+	suite.NoError(record.Err)
+	suite.Equal("env PLUGINFX_TEST_NOSUCH present", NewPluginView(record).Condition)
+}
+
+func (suite *ConditionSuite) TestPEnabled() {
+	var value float64
+	app := fxtest.New(
+		suite.T(),
+		P{
+			Anonymous: true,
+			Path:      samplePath,
+			Enabled:   GOOS(runtime.GOOS),
+			Symbols: Symbols{
+				Names: []interface{}{"New"},
+			},
+		}.Provide(),
+		fx.Populate(&value),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Equal(expectedNewValue, value)
+}
+
+func (suite *ConditionSuite) TestPError() {
+	app := fx.New(
+		P{
+			Anonymous: true,
+			Path:      samplePath,
+			Enabled:   ConditionFunc(func() (bool, error) { return false, errors.New("expected") }),
+		}.Provide(),
+	)
+
+	var pe *PluginError
+	suite.Require().True(errors.As(app.Err(), &pe))
+	suite.Equal(PhaseCondition, pe.Phase)
+	suite.Equal(samplePath, pe.Path)
+}
+
+func (suite *ConditionSuite) TestS() {
+	suite.Run("Disabled", func() {
+		var (
+			recorder eventRecorder
+			registry *Registry
+
+			app = fxtest.New(
+				suite.T(),
+				S{
+					Paths:   []string{samplePath, "/no/such/*.so"},
+					Enabled: Not(FileExists(samplePath)),
+					Logger:  &recorder,
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+				}.Provide(),
+				ProvideRegistry(),
+				fx.Populate(&registry),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.Len(recorder.events, 2)
+		suite.Equal(2, registry.Len())
+		for _, record := range registry.Records() {
+			suite.Equal(StatusDisabled, record.Status)
+		}
+	})
+
+	suite.Run("Error", func() {
+		var onError []error
+		app := fx.New(
+			S{
+				Paths:    []string{samplePath},
+				Enabled:  ConditionFunc(func() (bool, error) { return false, errors.New("expected") }),
+				Optional: true,
+				OnError:  func(err error) { onError = append(onError, err) },
+			}.Provide(),
+		)
+
+		suite.NoError(app.Err())
+		suite.Require().Len(onError, 1)
+
+		var pe *PluginError
+		suite.Require().True(errors.As(onError[0], &pe))
+		suite.Equal(PhaseCondition, pe.Phase)
+	})
+}
+
+func TestCondition(t *testing.T) {
+	suite.Run(t, new(ConditionSuite))
+}
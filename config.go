@@ -0,0 +1,185 @@
+package pluginfx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigureSymbol is the symbol that receives a plugin's configuration
+// when P.ConfigureSymbol is unset.
+const DefaultConfigureSymbol = "Configure"
+
+// ErrInvalidConfigure indicates that a plugin's configure symbol does not have
+// one of the supported signatures.
+var ErrInvalidConfigure = errors.New("A configure symbol must be a func(T) or func(T) error")
+
+// ConfigError indicates that a plugin's configuration could not be delivered.
+// When emitted by P, this error is wrapped in a *PluginError with PhaseConfigure.
+type ConfigError struct {
+	// Symbol is the name of the plugin's configure symbol.
+	Symbol string
+
+	// Type is the type the configuration was being decoded into.  This field
+	// is nil if the configure symbol was not usable.
+	Type reflect.Type
+
+	// Err is the underlying decoding error.
+	Err error
+}
+
+func (ce *ConfigError) Unwrap() error {
+	return ce.Err
+}
+
+func (ce *ConfigError) Error() string {
+	if ce.Type != nil {
+		return fmt.Sprintf("Unable to decode configuration of type %s for symbol %s: %s", ce.Type, ce.Symbol, ce.Err)
+	}
+
+	return fmt.Sprintf("Unable to configure symbol %s: %s", ce.Symbol, ce.Err)
+}
+
+// Decoder is a source of configuration for a plugin.
+type Decoder interface {
+	// Decode unmarshals configuration into v, which will always be a non-nil pointer.
+	Decode(v interface{}) error
+}
+
+// DecoderFunc is a function type that implements Decoder.
+type DecoderFunc func(interface{}) error
+
+// Decode invokes this function.
+func (df DecoderFunc) Decode(v interface{}) error {
+	return df(v)
+}
+
+// JSON returns a Decoder that unmarshals the given JSON document.
+func JSON(data []byte) Decoder {
+	return DecoderFunc(func(v interface{}) error {
+		return json.Unmarshal(data, v)
+	})
+}
+
+// YAML returns a Decoder that unmarshals the given YAML document.
+func YAML(data []byte) Decoder {
+	return DecoderFunc(func(v interface{}) error {
+		return yaml.NewDecoder(bytes.NewReader(data)).Decode(v)
+	})
+}
+
+// newDecoder produces a Decoder for the value of P.Config.
+func newDecoder(config interface{}) (Decoder, error) {
+	switch c := config.(type) {
+	case Decoder:
+		return c, nil
+
+	case []byte:
+		return JSON(c), nil
+
+	case string:
+		return JSON([]byte(c)), nil
+
+	default:
+		// maps, structs, etc. are remarshaled into the target type
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return JSON(data), nil
+	}
+}
+
+// deliverConfig decodes configuration into the parameter type of a configure
+// function and invokes that function with the result.
+func deliverConfig(name string, symbol interface{}, config interface{}) error {
+	fv := reflect.ValueOf(symbol)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.IsVariadic() || ft.NumOut() > 1 || (ft.NumOut() == 1 && ft.Out(0) != errType) {
+		return &ConfigError{
+			Symbol: name,
+			Err:    ErrInvalidConfigure,
+		}
+	}
+
+	decoder, err := newDecoder(config)
+	if err != nil {
+		return &ConfigError{
+			Symbol: name,
+			Type:   ft.In(0),
+			Err:    err,
+		}
+	}
+
+	var (
+		pt     = ft.In(0)
+		target reflect.Value
+	)
+
+	if pt.Kind() == reflect.Ptr {
+		target = reflect.New(pt.Elem())
+	} else {
+		target = reflect.New(pt)
+	}
+
+	if err := decoder.Decode(target.Interface()); err != nil {
+		return &ConfigError{
+			Symbol: name,
+			Type:   pt,
+			Err:    err,
+		}
+	}
+
+	if pt.Kind() != reflect.Ptr {
+		target = target.Elem()
+	}
+
+	results := fv.Call([]reflect.Value{target})
+	if len(results) == 1 && !results[0].IsNil() {
+		return results[0].Interface().(error)
+	}
+
+	return nil
+}
+
+// configure delivers this plugin's configuration, if any, to its configure symbol.
+// Any error is returned as a *PluginError.
+func (p P) configure(l loader, plugin Plugin) error {
+	if p.Config == nil {
+		return nil
+	}
+
+	name := p.ConfigureSymbol
+	if len(name) == 0 {
+		name = DefaultConfigureSymbol
+	}
+
+	symbol, err := Lookup(plugin, name)
+	if err != nil {
+		l.log(&SymbolMissing{Path: l.path, Name: name})
+		return &PluginError{
+			Path:   l.path,
+			Symbol: name,
+			Phase:  PhaseLookup,
+			Err:    err,
+		}
+	}
+
+	if err := deliverConfig(name, symbol, p.Config); err != nil {
+		l.log(&SymbolRejected{Path: l.path, Name: name, Err: err})
+		return &PluginError{
+			Path:   l.path,
+			Symbol: name,
+			Phase:  PhaseConfigure,
+			Err:    err,
+		}
+	}
+
+	l.log(&Configured{Path: l.path, Name: name})
+	return nil
+}
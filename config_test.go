@@ -0,0 +1,226 @@
+package pluginfx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type testConfig struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+type ConfigSuite struct {
+	PluginfxSuite
+}
+
+func (suite *ConfigSuite) TestConfigError() {
+	ce := &ConfigError{
+		Symbol: "Configure",
+		Type:   reflect.TypeOf(testConfig{}),
+		Err:    errors.New("expected"),
+	}
+
+	suite.Contains(ce.Error(), "pluginfx.testConfig")
+	suite.Equal(ce.Err, errors.Unwrap(ce))
+
+	ce.Type = nil
+	suite.Contains(ce.Error(), "Configure")
+}
+
+func (suite *ConfigSuite) testDeliver(config interface{}, expected testConfig) {
+	suite.Run("Value", func() {
+		var actual testConfig
+		suite.NoError(
+			deliverConfig("Configure", func(c testConfig) { actual = c }, config),
+		)
+
+		suite.Equal(expected, actual)
+	})
+
+	suite.Run("Pointer", func() {
+		var actual *testConfig
+		suite.NoError(
+			deliverConfig("Configure", func(c *testConfig) error { actual = c; return nil }, config),
+		)
+
+		suite.Require().NotNil(actual)
+		suite.Equal(expected, *actual)
+	})
+}
+
+func (suite *ConfigSuite) TestDeliverConfig() {
+	expected := testConfig{Name: "test", Count: 3}
+
+	suite.Run("Bytes", func() {
+		suite.testDeliver([]byte(`{"name": "test", "count": 3}`), expected)
+	})
+
+	suite.Run("String", func() {
+		suite.testDeliver(`{"name": "test", "count": 3}`, expected)
+	})
+
+	suite.Run("JSON", func() {
+		suite.testDeliver(JSON([]byte(`{"name": "test", "count": 3}`)), expected)
+	})
+
+	suite.Run("YAML", func() {
+		suite.testDeliver(YAML([]byte("name: test\ncount: 3\n")), expected)
+	})
+
+	suite.Run("Map", func() {
+		suite.testDeliver(map[string]interface{}{"name": "test", "count": 3}, expected)
+	})
+
+	suite.Run("InvalidSignature", func() {
+		for _, f := range []interface{}{
+			123,
+			func() {},
+			func(testConfig, int) {},
+			func(...testConfig) {},
+			func(testConfig) int { return 0 },
+		} {
+			var ce *ConfigError
+			err := deliverConfig("Configure", f, "{}")
+			suite.Require().True(errors.As(err, &ce))
+			suite.ErrorIs(err, ErrInvalidConfigure)
+			suite.Nil(ce.Type)
+		}
+	})
+
+	suite.Run("DecodeError", func() {
+		var ce *ConfigError
+		err := deliverConfig("Configure", func(testConfig) {}, `{"count": "not a number"}`)
+		suite.Require().True(errors.As(err, &ce))
+		suite.Equal("Configure", ce.Symbol)
+		suite.Equal(reflect.TypeOf(testConfig{}), ce.Type)
+	})
+
+	suite.Run("MarshalError", func() {
+		var ce *ConfigError
+		err := deliverConfig("Configure", func(testConfig) {}, func() {})
+		suite.Require().True(errors.As(err, &ce))
+	})
+
+	suite.Run("ConfigureError", func() {
+		expectedErr := errors.New("expected")
+		err := deliverConfig("Configure", func(testConfig) error { return expectedErr }, "{}")
+		suite.Equal(expectedErr, err)
+	})
+}
+
+func (suite *ConfigSuite) TestP() {
+	suite.Run("Success", func() {
+		var (
+			recorder eventRecorder
+			value    float64
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Config: map[string]interface{}{
+						"name": "configured",
+					},
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+					Logger: &recorder,
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.Equal(expectedNewValue, value)
+		suite.Contains(recorder.events, &Configured{Path: sampleOpenPath, Name: DefaultConfigureSymbol})
+
+		p := suite.openSuccess(Open(samplePath))
+		settings, err := p.Lookup("Settings")
+		suite.Require().NoError(err)
+		suite.Equal("configured", reflect.ValueOf(settings).Elem().FieldByName("Name").String())
+	})
+
+	suite.Run("DecodeError", func() {
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      samplePath,
+				Config:    `{"name": 123}`,
+			}.Provide(),
+		)
+
+		err := app.Err()
+		suite.Require().Error(err)
+
+		var pe *PluginError
+		suite.Require().True(errors.As(err, &pe))
+		suite.Equal(sampleOpenPath, pe.Path)
+		suite.Equal(DefaultConfigureSymbol, pe.Symbol)
+		suite.Equal(PhaseConfigure, pe.Phase)
+
+		var ce *ConfigError
+		suite.True(errors.As(err, &ce))
+	})
+
+	suite.Run("ConfigureError", func() {
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      samplePath,
+				Config:    YAML([]byte("name: ''")),
+			}.Provide(),
+		)
+
+		suite.Error(app.Err())
+	})
+
+	suite.Run("MissingSymbol", func() {
+		app := fx.New(
+			P{
+				Anonymous:       true,
+				Path:            samplePath,
+				Config:          "{}",
+				ConfigureSymbol: "Missing",
+			}.Provide(),
+		)
+
+		err := app.Err()
+		suite.Require().Error(err)
+		suite.True(IsMissingSymbolError(err))
+	})
+
+	suite.Run("Optional", func() {
+		var (
+			op OptionalPlugin
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Path:     samplePath,
+					Config:   "{}",
+					Optional: true,
+				}.Provide(),
+				fx.Populate(&op),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.False(op.Present())
+		suite.Error(op.Err)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	suite.Run(t, new(ConfigSuite))
+}
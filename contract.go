@@ -0,0 +1,204 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidContract indicates that a value in Symbols.Contracts was neither a
+// function nor a pointer to an interface.
+var ErrInvalidContract = errors.New("A contract must be a function or a pointer to an interface")
+
+// ContractError indicates that a plugin symbol does not match the type the host declared for it.
+type ContractError struct {
+	// Name is the symbol name.
+	Name string
+
+	// Expected is the contract type.  For function contracts, this is the exact function
+	// type the symbol must have.  For variable contracts, this is the interface type
+	// the symbol must implement.
+	Expected reflect.Type
+
+	// Actual is the type of the symbol exported by the plugin.
+	Actual reflect.Type
+}
+
+// Differences returns a line for each way in which the actual type departs from the contract.
+func (ce *ContractError) Differences() (d []string) {
+	switch {
+	case ce.Expected.Kind() == reflect.Interface:
+		for i := 0; i < ce.Expected.NumMethod(); i++ {
+			m := ce.Expected.Method(i)
+			if am, ok := ce.Actual.MethodByName(m.Name); !ok {
+				d = append(d, fmt.Sprintf("missing method %s%s", m.Name, strings.TrimPrefix(m.Type.String(), "func")))
+			} else if ce.Actual.Kind() != reflect.Interface && !methodMatches(m.Type, am.Type) {
+				d = append(d, fmt.Sprintf("method %s: expected %v, actual %v", m.Name, m.Type, dropReceiver(am.Type)))
+			}
+		}
+
+	case ce.Actual.Kind() != reflect.Func:
+		d = append(d, fmt.Sprintf("expected a function, actual %s", ce.Actual.Kind()))
+
+	default:
+		d = append(d, diffTypes("param", ce.Expected.NumIn(), ce.Actual.NumIn(), ce.Expected.In, ce.Actual.In)...)
+		d = append(d, diffTypes("result", ce.Expected.NumOut(), ce.Actual.NumOut(), ce.Expected.Out, ce.Actual.Out)...)
+		if ce.Expected.IsVariadic() != ce.Actual.IsVariadic() {
+			d = append(d, fmt.Sprintf("variadic: expected %t, actual %t", ce.Expected.IsVariadic(), ce.Actual.IsVariadic()))
+		}
+	}
+
+	return
+}
+
+// Error renders the contract and the symbol's actual type as a diff, followed by
+// each of the Differences.
+func (ce *ContractError) Error() string {
+	var o strings.Builder
+	fmt.Fprintf(&o, "Symbol %s does not match its contract\n", ce.Name)
+	if ce.Expected.Kind() == reflect.Interface {
+		fmt.Fprintf(&o, "- implements %v\n", ce.Expected)
+	} else {
+		fmt.Fprintf(&o, "- %v\n", ce.Expected)
+	}
+
+	fmt.Fprintf(&o, "+ %v", ce.Actual)
+	for _, d := range ce.Differences() {
+		o.WriteString("\n    ")
+		o.WriteString(d)
+	}
+
+	return o.String()
+}
+
+// diffTypes compares the parameters or results of two function types position by position.
+func diffTypes(label string, en, an int, expected, actual func(int) reflect.Type) (d []string) {
+	if en != an {
+		d = append(d, fmt.Sprintf("%s count: expected %d, actual %d", label, en, an))
+	}
+
+	for i := 0; i < en || i < an; i++ {
+		switch {
+		case i >= an:
+			d = append(d, fmt.Sprintf("%s %d: expected %v, actual none", label, i, expected(i)))
+
+		case i >= en:
+			d = append(d, fmt.Sprintf("%s %d: expected none, actual %v", label, i, actual(i)))
+
+		case expected(i) != actual(i):
+			d = append(d, fmt.Sprintf("%s %d: expected %v, actual %v", label, i, expected(i), actual(i)))
+		}
+	}
+
+	return
+}
+
+// dropReceiver returns a method's function type without its receiver.
+func dropReceiver(mt reflect.Type) reflect.Type {
+	in := make([]reflect.Type, 0, mt.NumIn())
+	for i := 1; i < mt.NumIn(); i++ {
+		in = append(in, mt.In(i))
+	}
+
+	out := make([]reflect.Type, 0, mt.NumOut())
+	for i := 0; i < mt.NumOut(); i++ {
+		out = append(out, mt.Out(i))
+	}
+
+	return reflect.FuncOf(in, out, mt.IsVariadic())
+}
+
+// methodMatches tests if a concrete method, which includes its receiver, has the
+// same signature as an interface method.
+func methodMatches(im, cm reflect.Type) bool {
+	return dropReceiver(cm) == im
+}
+
+// contractType returns the type a contract value describes.
+func contractType(contract interface{}) (reflect.Type, error) {
+	ct := reflect.TypeOf(contract)
+	switch {
+	case ct == nil:
+		return nil, ErrInvalidContract
+
+	case ct.Kind() == reflect.Func:
+		return ct, nil
+
+	case ct.Kind() == reflect.Ptr && ct.Elem().Kind() == reflect.Interface:
+		return ct.Elem(), nil
+
+	default:
+		return nil, ErrInvalidContract
+	}
+}
+
+// checkContract verifies a symbol against the contract declared for its name, if any.
+func (s Symbols) checkContract(name string, symbol interface{}) error {
+	contract, ok := s.Contracts[name]
+	if !ok {
+		return nil
+	}
+
+	expected, err := contractType(contract)
+	if err != nil {
+		return fmt.Errorf("Invalid contract %T for symbol %s: %w", contract, name, err)
+	}
+
+	actual := reflect.TypeOf(symbol)
+	if expected.Kind() == reflect.Interface {
+		// plugin variables are exported as pointers, so a variable of interface type
+		// or with value receivers satisfies the contract via its element type
+		if actual.Implements(expected) || (actual.Kind() == reflect.Ptr && actual.Elem().Implements(expected)) {
+			return nil
+		}
+	} else if actual == expected {
+		return nil
+	}
+
+	return &ContractError{
+		Name:     name,
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+// contractOnly returns the names in Contracts that do not appear in Names, in sorted order.
+func (s Symbols) contractOnly() (names []string) {
+	named := make(map[string]bool, len(s.Names))
+	for _, n := range s.Names {
+		switch name := n.(type) {
+		case string:
+			named[name] = true
+
+		case Annotated:
+			named[name.Target] = true
+		}
+	}
+
+	for name := range s.Contracts {
+		if !named[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// checkStep resolves a symbol that is verified against its contract but not bound.
+func (s Symbols) checkStep(p Plugin, name string) (st Step) {
+	st.Symbol = name
+	st.Kind = CheckSymbol
+
+	var symbol interface{}
+	symbol, st.Err = Lookup(p, name)
+	if st.Err == nil {
+		st.setType(reflect.TypeOf(symbol))
+		st.Err = s.checkContract(name, symbol)
+	}
+
+	st.Ignored = s.IgnoreMissing && IsMissingSymbolError(st.Err)
+	return
+}
@@ -0,0 +1,243 @@
+package pluginfx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type badStringer struct{}
+
+func (badStringer) String() int { return 0 }
+
+type ContractSuite struct {
+	PluginfxSuite
+}
+
+func (suite *ContractSuite) contractError(expectedName string, err error) *ContractError {
+	var ce *ContractError
+	suite.Require().True(errors.As(err, &ce))
+	suite.Equal(expectedName, ce.Name)
+	suite.NotEmpty(ce.Error())
+	return ce
+}
+
+func (suite *ContractSuite) TestFunction() {
+	var (
+		reader io.Reader = new(bytes.Buffer)
+
+		sm = NewSymbols(
+			"Constructor", func(string) (int, error) { return 0, nil },
+			"Invoke", func(int) {},
+			"Variadic", func(...string) {},
+			"Value", 123,
+			"Reader", &reader,
+		)
+	)
+
+	suite.Run("Match", func() {
+		plan := Symbols{
+			Names: []interface{}{"Constructor", "Invoke"},
+			Contracts: map[string]interface{}{
+				"Constructor": (func(string) (int, error))(nil),
+				"Invoke":      (func(int))(nil),
+			},
+		}.Plan(sm)
+
+		suite.NoError(plan.Err())
+		suite.Require().Len(plan.Steps, 2)
+		suite.Equal(ProvideSymbol, plan.Steps[0].Kind)
+		suite.Equal(InvokeSymbol, plan.Steps[1].Kind)
+	})
+
+	suite.Run("WrongResult", func() {
+		plan := Symbols{
+			Names: []interface{}{"Constructor"},
+			Contracts: map[string]interface{}{
+				"Constructor": (func(string) (float64, error))(nil),
+			},
+		}.Plan(sm)
+
+		ce := suite.contractError("Constructor", plan.Err())
+		suite.Equal([]string{"result 0: expected float64, actual int"}, ce.Differences())
+		suite.Equal(
+			"Symbol Constructor does not match its contract\n"+
+				"- func(string) (float64, error)\n"+
+				"+ func(string) (int, error)\n"+
+				"    result 0: expected float64, actual int",
+			ce.Error(),
+		)
+	})
+
+	suite.Run("WrongParams", func() {
+		plan := Symbols{
+			Names: []interface{}{Annotated{Name: "value", Target: "Constructor"}},
+			Contracts: map[string]interface{}{
+				"Constructor": (func(string, int) (int, error))(nil),
+			},
+		}.Plan(sm)
+
+		ce := suite.contractError("Constructor", plan.Err())
+		suite.Equal(
+			[]string{
+				"param count: expected 2, actual 1",
+				"param 1: expected int, actual none",
+			},
+			ce.Differences(),
+		)
+	})
+
+	suite.Run("Variadic", func() {
+		plan := Symbols{
+			Names: []interface{}{"Variadic"},
+			Contracts: map[string]interface{}{
+				"Variadic": (func([]string))(nil),
+			},
+		}.Plan(sm)
+
+		ce := suite.contractError("Variadic", plan.Err())
+		suite.Contains(ce.Differences(), "variadic: expected false, actual true")
+	})
+
+	suite.Run("NotAFunction", func() {
+		plan := Symbols{
+			Contracts: map[string]interface{}{
+				"Value": (func() int)(nil),
+			},
+		}.Plan(sm)
+
+		ce := suite.contractError("Value", plan.Err())
+		suite.Equal([]string{"expected a function, actual ptr"}, ce.Differences())
+	})
+}
+
+func (suite *ContractSuite) TestInterface() {
+	var (
+		reader io.Reader = new(bytes.Buffer)
+
+		sm = NewSymbols(
+			"Buffer", new(bytes.Buffer),
+			"Reader", &reader,
+			"Value", 123,
+			"BadStringer", badStringer{},
+		)
+	)
+
+	suite.Run("Match", func() {
+		plan := Symbols{
+			Contracts: map[string]interface{}{
+				"Buffer": (*io.Writer)(nil),
+				"Reader": (*io.Reader)(nil),
+			},
+		}.Plan(sm)
+
+		suite.NoError(plan.Err())
+		suite.Require().Len(plan.Steps, 2)
+		suite.Equal("Buffer", plan.Steps[0].Symbol)
+		suite.Equal(CheckSymbol, plan.Steps[0].Kind)
+		suite.Equal("Reader", plan.Steps[1].Symbol)
+		suite.Equal(CheckSymbol, plan.Steps[1].Kind)
+		suite.Contains(plan.String(), "CHECK Buffer")
+
+		app := fxtest.New(suite.T(), plan.Options())
+		app.RequireStart()
+		app.RequireStop()
+	})
+
+	suite.Run("MissingMethod", func() {
+		plan := Symbols{
+			Contracts: map[string]interface{}{
+				"Value": (*fmt.Stringer)(nil),
+			},
+		}.Plan(sm)
+
+		ce := suite.contractError("Value", plan.Err())
+		suite.Equal([]string{"missing method String() string"}, ce.Differences())
+		suite.Contains(ce.Error(), "- implements fmt.Stringer")
+	})
+
+	suite.Run("WrongMethod", func() {
+		plan := Symbols{
+			Contracts: map[string]interface{}{
+				"BadStringer": (*fmt.Stringer)(nil),
+			},
+		}.Plan(sm)
+
+		ce := suite.contractError("BadStringer", plan.Err())
+		suite.Equal([]string{"method String: expected func() string, actual func() int"}, ce.Differences())
+	})
+}
+
+func (suite *ContractSuite) TestMissing() {
+	sm := NewSymbols()
+
+	suite.Run("Ignored", func() {
+		plan := Symbols{
+			Contracts: map[string]interface{}{
+				"Missing": (*io.Reader)(nil),
+			},
+			IgnoreMissing: true,
+		}.Plan(sm)
+
+		suite.NoError(plan.Err())
+		suite.Require().Len(plan.Steps, 1)
+		suite.True(plan.Steps[0].Ignored)
+	})
+
+	suite.Run("Error", func() {
+		plan := Symbols{
+			Contracts: map[string]interface{}{
+				"Missing": (*io.Reader)(nil),
+			},
+		}.Plan(sm)
+
+		suite.True(IsMissingSymbolError(plan.Err()))
+	})
+}
+
+func (suite *ContractSuite) TestInvalidContract() {
+	plan := Symbols{
+		Names: []interface{}{"Func"},
+		Contracts: map[string]interface{}{
+			"Func": 123,
+		},
+	}.Plan(NewSymbols("Func", func() {}))
+
+	suite.ErrorIs(plan.Err(), ErrInvalidContract)
+}
+
+func (suite *ContractSuite) TestP() {
+	app := fx.New(
+		P{
+			Anonymous: true,
+			Path:      samplePath,
+			Symbols: Symbols{
+				Names: []interface{}{"New"},
+				Contracts: map[string]interface{}{
+					"New":   (func() (int, error))(nil),
+					"Value": (*fmt.Stringer)(nil),
+				},
+			},
+		}.Provide(),
+	)
+
+	err := app.Err()
+	suite.Require().Error(err)
+	suite.contractError("New", err)
+
+	var pe *PluginError
+	suite.Require().True(errors.As(err, &pe))
+	suite.Equal(sampleOpenPath, pe.Path)
+	suite.Equal(PhaseProvide, pe.Phase)
+	suite.Contains(err.Error(), "Symbol Value does not match its contract")
+}
+
+func TestContract(t *testing.T) {
+	suite.Run(t, new(ContractSuite))
+}
@@ -0,0 +1,147 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// OpenCause classifies why a plugin could not be opened.
+type OpenCause string
+
+const (
+	// CauseUnknown is a failure that could not be classified.
+	CauseUnknown OpenCause = "unknown"
+
+	// CauseNotFound indicates that there is no file at the plugin's path.
+	CauseNotFound OpenCause = "not found"
+
+	// CausePermission indicates that the plugin file, or a directory leading to it,
+	// could not be read or mapped into memory.
+	CausePermission OpenCause = "permission denied"
+
+	// CauseNotPlugin indicates that the file is not a shared object for this platform,
+	// e.g. it is not an ELF file or was built for another architecture.
+	CauseNotPlugin OpenCause = "not a plugin"
+
+	// CauseGoVersion indicates that the plugin was built with a different Go toolchain,
+	// or different toolchain flags, than the host.  The runtime reports this as a standard
+	// library package with a different version.
+	CauseGoVersion OpenCause = "go version mismatch"
+
+	// CausePackageVersion indicates that the plugin and the host were built with different
+	// versions of a non-standard package.  OpenError.Package holds that package's path.
+	CausePackageVersion OpenCause = "package version mismatch"
+
+	// CauseAlreadyLoaded indicates that the same plugin, possibly via a copy at a different
+	// path, was already loaded into this process.
+	CauseAlreadyLoaded OpenCause = "already loaded"
+
+	// CauseTimeout indicates that the plugin did not finish opening, typically because an
+	// init function blocked, before its deadline passed or its context was canceled.
+	CauseTimeout OpenCause = "timeout"
+)
+
+const packageVersionMessage = "plugin was built with a different version of package "
+
+// notPluginMessages are fragments of dynamic loader errors for files that are not
+// loadable shared objects.
+var notPluginMessages = []string{
+	"invalid ELF header",
+	"file too short",
+	"wrong ELF class",
+	"only ET_DYN and ET_EXEC can be loaded",
+	"ELF load command",
+	"cannot dynamically load executable",
+	"not a mach-o file",
+	"mach-o, but wrong architecture",
+}
+
+// isStandardPackage tests if an import path belongs to the standard library, whose
+// paths have no dot in their first element.
+func isStandardPackage(pkg string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".")
+}
+
+// classifyOpenError determines the cause of a plugin.Open error from its message.  For a
+// package version mismatch, the package path is also returned.
+func classifyOpenError(msg string) (OpenCause, string) {
+	switch {
+	case strings.Contains(msg, "plugin already loaded"):
+		return CauseAlreadyLoaded, ""
+
+	case strings.Contains(msg, packageVersionMessage):
+		pkg := msg[strings.Index(msg, packageVersionMessage)+len(packageVersionMessage):]
+		pkg = strings.TrimSuffix(pkg, " (previous failure)")
+		if isStandardPackage(pkg) {
+			return CauseGoVersion, pkg
+		}
+
+		return CausePackageVersion, pkg
+
+	case strings.Contains(msg, "realpath failed"),
+		strings.Contains(msg, "No such file or directory"),
+		strings.Contains(msg, "image not found"):
+		return CauseNotFound, ""
+
+	case strings.Contains(msg, "Permission denied"),
+		strings.Contains(msg, "failed to map segment"),
+		strings.Contains(msg, "Operation not permitted"):
+		return CausePermission, ""
+	}
+
+	for _, m := range notPluginMessages {
+		if strings.Contains(msg, m) {
+			return CauseNotPlugin, ""
+		}
+	}
+
+	return CauseUnknown, ""
+}
+
+// diagnose classifies a plugin.Open error for the given path.  The plugin package reports
+// every failure to resolve a path as "realpath failed", so the file is examined to tell a
+// missing file apart from an inaccessible one.
+func diagnose(path string, err error) (OpenCause, string) {
+	cause, pkg := classifyOpenError(err.Error())
+	if cause == CauseNotFound {
+		if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrPermission) {
+			cause = CausePermission
+		}
+	}
+
+	return cause, pkg
+}
+
+// Remediation returns advice on how to correct this error, suitable for showing to
+// whoever deployed the plugin.
+func (oe *OpenError) Remediation() string {
+	switch oe.Cause {
+	case CauseNotFound:
+		return fmt.Sprintf("Check that %s exists, and that its path is correct after expansion and search", oe.Path)
+
+	case CausePermission:
+		return fmt.Sprintf("Make %s, and each directory leading to it, readable by this process", oe.Path)
+
+	case CauseNotPlugin:
+		return "Rebuild the plugin with go build -buildmode=plugin for this platform"
+
+	case CauseGoVersion:
+		return fmt.Sprintf("Rebuild the plugin with %s and the same build flags as the host, e.g. -race and -trimpath", HostPlatform().GoVersion)
+
+	case CausePackageVersion:
+		return fmt.Sprintf("Rebuild the plugin against the same version of %s as the host, e.g. by aligning go.mod", oe.Package)
+
+	case CauseAlreadyLoaded:
+		return "Load each plugin only once per process; a copy of a loaded plugin cannot be loaded again"
+
+	case CauseTimeout:
+		return "Check what the plugin's init functions wait on, or allow more time via OpenTimeout"
+
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,162 @@
+package pluginfx
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DiagnoseSuite struct {
+	PluginfxSuite
+}
+
+func (suite *DiagnoseSuite) TestClassifyOpenError() {
+	testData := []struct {
+		msg   string
+		cause OpenCause
+		pkg   string
+	}{
+		{
+			msg:   `plugin.Open("/plugins/auth.so"): realpath failed`,
+			cause: CauseNotFound,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): /plugins/auth.so: cannot open shared object file: No such file or directory`,
+			cause: CauseNotFound,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): /plugins/auth.so: cannot open shared object file: Permission denied`,
+			cause: CausePermission,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): /plugins/auth.so: failed to map segment from shared object`,
+			cause: CausePermission,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): /plugins/auth.so: invalid ELF header`,
+			cause: CauseNotPlugin,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): /plugins/auth.so: file too short`,
+			cause: CauseNotPlugin,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): /plugins/auth.so: wrong ELF class: ELFCLASS32`,
+			cause: CauseNotPlugin,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): dlopen(/plugins/auth.so, 0x0001): tried: '/plugins/auth.so' (not a mach-o file)`,
+			cause: CauseNotPlugin,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): plugin was built with a different version of package runtime/internal/sys`,
+			cause: CauseGoVersion,
+			pkg:   "runtime/internal/sys",
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): plugin was built with a different version of package internal/abi`,
+			cause: CauseGoVersion,
+			pkg:   "internal/abi",
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): plugin was built with a different version of package github.com/xmidt-org/pluginfx`,
+			cause: CausePackageVersion,
+			pkg:   "github.com/xmidt-org/pluginfx",
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): plugin was built with a different version of package go.uber.org/zap/zapcore (previous failure)`,
+			cause: CausePackageVersion,
+			pkg:   "go.uber.org/zap/zapcore",
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): plugin already loaded`,
+			cause: CauseAlreadyLoaded,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): plugin already loaded (previous failure)`,
+			cause: CauseAlreadyLoaded,
+		},
+		{
+			msg:   `plugin.Open("/plugins/auth"): something unexpected`,
+			cause: CauseUnknown,
+		},
+	}
+
+	for _, record := range testData {
+		suite.Run(record.msg, func() {
+			cause, pkg := classifyOpenError(record.msg)
+			suite.Equal(record.cause, cause)
+			suite.Equal(record.pkg, pkg)
+		})
+	}
+}
+
+func (suite *DiagnoseSuite) TestRemediation() {
+	for _, cause := range []OpenCause{CauseNotFound, CausePermission, CauseNotPlugin, CauseGoVersion, CauseAlreadyLoaded} {
+		oe := &OpenError{Path: "test.so", Cause: cause}
+		suite.NotEmpty(oe.Remediation(), cause)
+	}
+
+	oe := &OpenError{Path: "test.so", Cause: CausePackageVersion, Package: "example.com/shared"}
+	suite.Contains(oe.Remediation(), "example.com/shared")
+
+	oe = &OpenError{Path: "test.so", Cause: CauseUnknown}
+	suite.Empty(oe.Remediation())
+}
+
+func (suite *DiagnoseSuite) TestOpen() {
+	suite.Run("NotFound", func() {
+		_, err := Open("nosuch.so")
+		oe := suite.openError("nosuch.so", err)
+		suite.Equal(CauseNotFound, oe.Cause)
+		suite.ErrorIs(err, fs.ErrNotExist)
+		suite.False(errors.Is(err, fs.ErrPermission))
+	})
+
+	suite.Run("NotPlugin", func() {
+		path := filepath.Join(suite.T().TempDir(), "text.so")
+		suite.Require().NoError(os.WriteFile(path, []byte("this is not a plugin"), 0600))
+
+		_, err := Open(path)
+		oe := suite.openError(path, err)
+		suite.Equal(CauseNotPlugin, oe.Cause)
+		suite.False(errors.Is(err, fs.ErrNotExist))
+	})
+
+	suite.Run("Permission", func() {
+		if os.Geteuid() == 0 {
+			suite.T().Skip("file permissions do not apply to root")
+		}
+
+		path := filepath.Join(suite.T().TempDir(), "unreadable.so")
+		suite.Require().NoError(os.WriteFile(path, []byte("unreadable"), 0))
+
+		_, err := Open(path)
+		oe := suite.openError(path, err)
+		suite.Equal(CausePermission, oe.Cause)
+		suite.ErrorIs(err, fs.ErrPermission)
+	})
+
+	suite.Run("AlreadyLoaded", func() {
+		suite.openSuccess(Open(samplePath))
+
+		data, err := os.ReadFile(samplePath)
+		suite.Require().NoError(err)
+
+		path := filepath.Join(suite.T().TempDir(), "copy.so")
+		suite.Require().NoError(os.WriteFile(path, data, 0700))
+
+		_, err = Open(path)
+		oe := suite.openError(path, err)
+		suite.Equal(CauseAlreadyLoaded, oe.Cause)
+		suite.NotEmpty(oe.Remediation())
+	})
+}
+
+func TestDiagnose(t *testing.T) {
+	suite.Run(t, new(DiagnoseSuite))
+}
@@ -0,0 +1,246 @@
+package pluginfx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// DuplicatePolicy decides what happens when a plugin file is loaded through more than one path.
+type DuplicatePolicy int
+
+const (
+	// DuplicateSkip ignores each duplicate path.  The duplicate is not opened, and none of
+	// its symbols or components are provided.  This is the default.
+	DuplicateSkip DuplicatePolicy = iota
+
+	// DuplicateFail fails application startup with a *DuplicateError for each duplicate path.
+	DuplicateFail
+
+	// DuplicateAlias provides the plugin component for each duplicate path, according to its
+	// Name and Group, but binds none of its symbols, lifecycle, or configuration again.  The
+	// component is the plugin already loaded from the original, subject to the same Preflight
+	// check and OpenTimeout as any other open.
+	DuplicateAlias
+)
+
+// String returns a human-readable label for this policy.
+func (dp DuplicatePolicy) String() string {
+	switch dp {
+	case DuplicateSkip:
+		return "skip"
+
+	case DuplicateFail:
+		return "fail"
+
+	case DuplicateAlias:
+		return "alias"
+
+	default:
+		return "unknown"
+	}
+}
+
+// DuplicateError indicates that the same plugin file was loaded through more than one path.
+type DuplicateError struct {
+	// File is the canonical path of the plugin file, with all symbolic links resolved.
+	File string
+
+	// Paths are the paths that resolved to File, in the order they were loaded.  The first
+	// path is the one the plugin was actually loaded from.  A plugin read from an fs.FS is
+	// listed by its name within the FS, and a downloaded plugin by its URL.
+	Paths []string
+}
+
+func (de *DuplicateError) Error() string {
+	return fmt.Sprintf("Plugin file %s was loaded through multiple paths: %s", de.File, strings.Join(de.Paths, ", "))
+}
+
+// trackedFile is a plugin file known to a Tracker.
+type trackedFile struct {
+	canonical string
+	info      os.FileInfo
+	paths     []string
+}
+
+// duplicate describes a path that resolved to a file that was already tracked.
+type duplicate struct {
+	policy DuplicatePolicy
+	file   string
+	path   string
+	paths  []string
+}
+
+// original is the path that the duplicated file was first loaded through.
+func (d *duplicate) original() string {
+	return d.paths[0]
+}
+
+// Tracker detects plugin files that are loaded more than once, whether through the same
+// path, a symbolic link, or a hard link.  Files are identified by their canonical path and
+// by their device and inode, as in os.SameFile.  Share a single Tracker between every P and
+// S in an fx.App to deduplicate across all of them.
+//
+// The zero value of this type is ready to use, and applies DuplicateSkip.
+type Tracker struct {
+	// Policy decides what happens to each duplicate path.
+	Policy DuplicatePolicy
+
+	lock  sync.Mutex
+	files []*trackedFile
+}
+
+// track records that a plugin is being loaded from the local file, which is reported as path.
+// If file was already tracked, the returned duplicate describes it.  Otherwise, including when
+// this Tracker is nil or file cannot be examined, this method returns nil.
+func (t *Tracker) track(file, path string) *duplicate {
+	if t == nil {
+		return nil
+	}
+
+	canonical, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return nil
+	}
+
+	canonical = absolute(canonical)
+	info, err := os.Stat(canonical)
+	if err != nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, tf := range t.files {
+		if tf.canonical == canonical || os.SameFile(tf.info, info) {
+			tf.paths = append(tf.paths, path)
+			return &duplicate{
+				policy: t.Policy,
+				file:   tf.canonical,
+				path:   path,
+				paths:  append([]string(nil), tf.paths...),
+			}
+		}
+	}
+
+	t.files = append(t.files, &trackedFile{
+		canonical: canonical,
+		info:      info,
+		paths:     []string{path},
+	})
+
+	return nil
+}
+
+// turn orders tracking across the concurrent tasks of an S, so that the original of each
+// duplicate is always the one earliest in Paths, no matter which file is local first.
+type turn struct {
+	wait <-chan struct{}
+	done chan struct{}
+	once *sync.Once
+}
+
+// next returns the turn that follows this one.
+func (t turn) next() turn {
+	return turn{
+		wait: t.done,
+		done: make(chan struct{}),
+		once: new(sync.Once),
+	}
+}
+
+// track waits for every earlier turn to end, then tracks file.  A nil Tracker ends this
+// turn without waiting, as there is nothing to order.
+func (t turn) track(tracker *Tracker, file, path string) *duplicate {
+	defer t.end()
+	if tracker == nil {
+		return nil
+	}
+
+	if t.wait != nil {
+		<-t.wait
+	}
+
+	return tracker.track(file, path)
+}
+
+// end lets the next turn proceed.  A task must end its turn even if it never tracks
+// anything, so this method may be called more than once.
+func (t turn) end() {
+	t.once.Do(func() { close(t.done) })
+}
+
+// openAlias opens the canonical file of a duplicate, which the runtime resolves to the plugin
+// already loaded from it.  The same preflight check and deadline apply as to the original, so
+// an original whose init functions are blocked cannot block its aliases forever.
+func (l loader) openAlias(file string) (Plugin, error) {
+	var (
+		p   Plugin
+		err error
+	)
+
+	if l.preflight {
+		err = Preflight(file)
+	}
+
+	if err == nil {
+		ctx, cancel := l.openContext()
+		p, err = OpenContext(ctx, file)
+		cancel()
+	}
+
+	if err != nil {
+		return nil, &PluginError{
+			Path:  l.path,
+			Phase: PhaseOpen,
+			Err:   err,
+		}
+	}
+
+	return p, nil
+}
+
+// provideDuplicate handles a path that resolved to a plugin file that was already loaded.
+func (p P) provideDuplicate(l loader, d *duplicate) fx.Option {
+	if d.policy == DuplicateFail {
+		return p.fail(l, &PluginError{
+			Path:  l.path,
+			Phase: PhaseOpen,
+			Err: &DuplicateError{
+				File:  d.file,
+				Paths: d.paths,
+			},
+		})
+	}
+
+	l.log(&Duplicate{
+		Path:     d.path,
+		Original: d.original(),
+		Alias:    d.policy == DuplicateAlias,
+	})
+
+	var (
+		plugin Plugin
+		err    error
+	)
+
+	if d.policy == DuplicateAlias {
+		plugin, err = l.openAlias(d.file)
+	}
+
+	switch {
+	case p.Optional:
+		return fx.Options(l.provideEntry(), p.provideOptional(l, plugin, err))
+
+	case d.policy == DuplicateAlias:
+		return fx.Options(l.provideEntry(), p.component(plugin, err))
+
+	default:
+		return l.provideEntry()
+	}
+}
@@ -0,0 +1,397 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
+)
+
+type DuplicateSuite struct {
+	PluginfxSuite
+
+	symlink  string
+	hardlink string
+}
+
+func (suite *DuplicateSuite) SetupSuite() {
+	dir := suite.T().TempDir()
+	suite.symlink = filepath.Join(dir, "symlink.so")
+	suite.hardlink = filepath.Join(dir, "hardlink.so")
+
+	suite.Require().NoError(os.Symlink(sampleOpenPath, suite.symlink))
+	if err := os.Link(sampleOpenPath, suite.hardlink); err != nil {
+		// hard links across file systems are not possible, so fall back to a second symlink
+		suite.Require().NoError(os.Symlink(sampleOpenPath, suite.hardlink))
+	}
+}
+
+func (suite *DuplicateSuite) TestPolicyString() {
+	suite.Equal("skip", DuplicateSkip.String())
+	suite.Equal("fail", DuplicateFail.String())
+	suite.Equal("alias", DuplicateAlias.String())
+	suite.Equal("unknown", DuplicatePolicy(-1).String())
+}
+
+func (suite *DuplicateSuite) TestTrack() {
+	suite.Run("Nil", func() {
+		var t *Tracker
+		suite.Nil(t.track(samplePath, samplePath))
+		suite.Nil(t.track(samplePath, samplePath))
+	})
+
+	suite.Run("Missing", func() {
+		var t Tracker
+		suite.Nil(t.track("nosuch.so", "nosuch.so"))
+		suite.Nil(t.track("nosuch.so", "nosuch.so"))
+	})
+
+	suite.Run("Distinct", func() {
+		var t Tracker
+		suite.Nil(t.track(samplePath, samplePath))
+		suite.Nil(t.track(embeddedPath, embeddedPath))
+	})
+
+	suite.Run("Duplicates", func() {
+		t := Tracker{Policy: DuplicateAlias}
+		suite.Nil(t.track(samplePath, samplePath))
+
+		d := t.track(sampleOpenPath, sampleOpenPath)
+		suite.Require().NotNil(d)
+		suite.Equal(DuplicateAlias, d.policy)
+		suite.Equal(samplePath, d.original())
+		suite.Equal([]string{samplePath, sampleOpenPath}, d.paths)
+
+		d = t.track(suite.symlink, suite.symlink)
+		suite.Require().NotNil(d)
+		suite.Equal([]string{samplePath, sampleOpenPath, suite.symlink}, d.paths)
+
+		d = t.track(suite.hardlink, suite.hardlink)
+		suite.Require().NotNil(d)
+		suite.Equal(samplePath, d.original())
+		suite.Equal([]string{samplePath, sampleOpenPath, suite.symlink, suite.hardlink}, d.paths)
+	})
+}
+
+func (suite *DuplicateSuite) TestDuplicateError() {
+	de := &DuplicateError{
+		File:  "/plugins/test.so",
+		Paths: []string{"test.so", "link.so"},
+	}
+
+	suite.Contains(de.Error(), "/plugins/test.so")
+	suite.Contains(de.Error(), "test.so, link.so")
+}
+
+func (suite *DuplicateSuite) TestSkip() {
+	var (
+		tracker  Tracker
+		recorder eventRecorder
+		value    float64
+		registry *Registry
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Anonymous: true,
+				Path:      samplePath,
+				Tracker:   &tracker,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			S{
+				Paths:   []string{suite.symlink, suite.hardlink},
+				Tracker: &tracker,
+				Logger:  &recorder,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&value, &registry),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.Equal(67.5, value)
+	suite.Equal(
+		[]Event{
+			&Duplicate{Path: suite.symlink, Original: sampleOpenPath},
+			&Duplicate{Path: suite.hardlink, Original: sampleOpenPath},
+		},
+		recorder.events,
+	)
+
+	for _, path := range []string{suite.symlink, suite.hardlink} {
+		r, ok := registry.Get(path)
+		suite.Require().True(ok)
+		suite.Equal(StatusDuplicate, r.Status)
+		suite.Equal(sampleOpenPath, r.Original)
+		suite.Empty(r.Symbols)
+	}
+
+	suite.Run("Optional", func() {
+		var (
+			tracker Tracker
+			plugins []OptionalPlugin
+
+			app = fxtest.New(
+				suite.T(),
+				S{
+					Group:    "plugins",
+					Paths:    []string{samplePath, suite.symlink},
+					Tracker:  &tracker,
+					Optional: true,
+				}.Provide(),
+				fx.Invoke(
+					func(in struct {
+						fx.In
+						Plugins []OptionalPlugin `group:"plugins"`
+					}) {
+						plugins = in.Plugins
+					},
+				),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.Len(plugins, 2)
+	})
+}
+
+func (suite *DuplicateSuite) TestFail() {
+	var (
+		tracker = Tracker{Policy: DuplicateFail}
+		app     = fx.New(
+			P{
+				Anonymous: true,
+				Path:      samplePath,
+				Tracker:   &tracker,
+			}.Provide(),
+			S{
+				Paths:   []string{suite.symlink, suite.hardlink},
+				Tracker: &tracker,
+			}.Provide(),
+		)
+	)
+
+	err := app.Err()
+	suite.Require().Error(err)
+
+	var de *DuplicateError
+	suite.Require().True(errors.As(err, &de))
+	suite.Equal(sampleOpenPath, de.File)
+
+	// the last duplicate lists every path that resolved to the same file
+	suite.Contains(err.Error(), sampleOpenPath+", "+suite.symlink+", "+suite.hardlink)
+
+	suite.Run("Optional", func() {
+		var (
+			tracker = Tracker{Policy: DuplicateFail}
+			skipped []error
+			op      OptionalPlugin
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Tracker:   &tracker,
+				}.Provide(),
+				P{
+					Name:     "duplicate",
+					Path:     suite.symlink,
+					Tracker:  &tracker,
+					Optional: true,
+					OnError:  func(err error) { skipped = append(skipped, err) },
+				}.Provide(),
+				fx.Invoke(
+					func(in struct {
+						fx.In
+						Plugin OptionalPlugin `name:"duplicate"`
+					}) {
+						op = in.Plugin
+					},
+				),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.False(op.Present())
+		suite.Require().Len(skipped, 1)
+		suite.True(errors.As(skipped[0], &de))
+		suite.Equal([]string{sampleOpenPath, suite.symlink}, de.Paths)
+	})
+}
+
+func (suite *DuplicateSuite) TestAlias() {
+	var (
+		tracker = Tracker{Policy: DuplicateAlias}
+		value   float64
+
+		original, alias Plugin
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Name:    "original",
+				Path:    samplePath,
+				Tracker: &tracker,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			P{
+				Name:    "alias",
+				Path:    suite.hardlink,
+				Tracker: &tracker,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			fx.Populate(&value),
+			fx.Invoke(func(in struct {
+				fx.In
+				Original Plugin `name:"original"`
+				Alias    Plugin `name:"alias"`
+			}) {
+				original, alias = in.Original, in.Alias
+			}),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.Equal(67.5, value)
+	suite.Require().NotNil(alias)
+	suite.Equal(original, alias)
+
+	symbol, err := Lookup(alias, "New")
+	suite.Require().NoError(err)
+	suite.IsType((func() (float64, error))(nil), symbol)
+}
+
+func (suite *DuplicateSuite) TestConcurrentFS() {
+	// every file in the FS has the same content, so each is copied to the same cached file
+	data, err := os.ReadFile(embeddedPath)
+	suite.Require().NoError(err)
+
+	var (
+		fsys  = make(fstest.MapFS)
+		names []string
+	)
+
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("plugin%d.so", i)
+		fsys[name] = &fstest.MapFile{Data: data, Mode: 0500}
+		names = append(names, name)
+	}
+
+	for i := 0; i < 5; i++ {
+		tracker := Tracker{Policy: DuplicateFail}
+		app := fx.New(
+			S{
+				Paths:       []string{"*.so"},
+				FS:          fsys,
+				CacheDir:    testCacheDir,
+				Concurrency: 4,
+				Tracker:     &tracker,
+			}.Provide(),
+		)
+
+		errs := multierr.Errors(app.Err())
+		suite.Require().Len(errs, len(names)-1)
+		for j, err := range errs {
+			// the original is always the first match, regardless of which copy finishes first
+			var de *DuplicateError
+			suite.Require().True(errors.As(err, &de))
+			suite.Equal(names[:j+2], de.Paths)
+		}
+	}
+}
+
+func (suite *DuplicateSuite) TestReportedNames() {
+	data, err := os.ReadFile(embeddedPath)
+	suite.Require().NoError(err)
+
+	suite.Run("FS", func() {
+		var (
+			tracker = Tracker{Policy: DuplicateFail}
+			fsys    = fstest.MapFS{
+				"a.so": &fstest.MapFile{Data: data, Mode: 0500},
+				"b.so": &fstest.MapFile{Data: data, Mode: 0500},
+			}
+
+			app = fx.New(
+				P{Anonymous: true, Path: "a.so", FS: fsys, CacheDir: testCacheDir, Tracker: &tracker}.Provide(),
+				P{Anonymous: true, Path: "b.so", FS: fsys, CacheDir: testCacheDir, Tracker: &tracker}.Provide(),
+			)
+		)
+
+		var de *DuplicateError
+		suite.Require().True(errors.As(app.Err(), &de))
+		suite.Equal([]string{"a.so", "b.so"}, de.Paths)
+	})
+
+	suite.Run("URL", func() {
+		var (
+			tracker  = Tracker{Policy: DuplicateSkip}
+			recorder eventRecorder
+			registry *Registry
+
+			server = httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+				response.Write(data)
+			}))
+		)
+
+		defer server.Close()
+		app := fxtest.New(
+			suite.T(),
+			P{Anonymous: true, Path: server.URL + "/a.so", CacheDir: testCacheDir, Tracker: &tracker}.Provide(),
+			P{Anonymous: true, Path: server.URL + "/b.so", CacheDir: testCacheDir, Tracker: &tracker, Logger: &recorder}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&registry),
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		var duplicates []Event
+		for _, e := range recorder.events {
+			if _, ok := e.(*Duplicate); ok {
+				duplicates = append(duplicates, e)
+			}
+		}
+
+		suite.Equal(
+			[]Event{&Duplicate{Path: server.URL + "/b.so", Original: server.URL + "/a.so"}},
+			duplicates,
+		)
+
+		r, ok := registry.Get(server.URL + "/b.so")
+		suite.Require().True(ok)
+		suite.Equal(StatusDuplicate, r.Status)
+		suite.Equal(server.URL+"/a.so", r.Original)
+	})
+}
+
+func TestDuplicate(t *testing.T) {
+	suite.Run(t, new(DuplicateSuite))
+}
@@ -0,0 +1,223 @@
+package pluginfx
+
+import (
+	"reflect"
+	"time"
+)
+
+// Event is something that happened while loading a plugin and binding
+// its symbols to an enclosing fx.App.  The concrete event types in this
+// package are the only implementations.
+type Event interface {
+	event()
+}
+
+func (*Opened) event()         {}
+func (*OpenFailed) event()     {}
+func (*SymbolBound) event()    {}
+func (*SymbolMissing) event()  {}
+func (*SymbolRejected) event() {}
+func (*LifecycleBound) event() {}
+func (*Skipped) event()        {}
+func (*Configured) event()     {}
+func (*Disabled) event()       {}
+func (*Selected) event()       {}
+func (*Fetched) event()        {}
+func (*Built) event()          {}
+func (*Duplicate) event()      {}
+
+// Opened is emitted when a plugin was successfully opened.
+type Opened struct {
+	// Path is the path the plugin was opened from, after any expansion.
+	Path string
+}
+
+// Selected is emitted when a plugin file was chosen from a list of candidates,
+// either from a path template or from fallback paths.
+type Selected struct {
+	// Path is the chosen file or, for S, the chosen glob pattern.
+	Path string
+
+	// Candidates are all the candidates, in order of preference.
+	Candidates []string
+}
+
+// Fetched is emitted when a plugin configured with a URL is available locally.
+type Fetched struct {
+	// URL is the plugin URL.
+	URL string
+
+	// Path is the local file that the plugin will be opened from.
+	Path string
+
+	// Cached indicates that no download was necessary, because the server reported
+	// that the cached copy was not modified or because the URL referred to a local file.
+	Cached bool
+}
+
+// Built is emitted when a plugin configured with a Go package directory is available locally.
+type Built struct {
+	// Source is the absolute path of the package directory.
+	Source string
+
+	// Path is the local file that the plugin will be opened from.
+	Path string
+
+	// Cached indicates that no build was necessary, because an artifact built from the same
+	// source, toolchain, and module graph already existed.
+	Cached bool
+}
+
+// OpenFailed is emitted when a plugin could not be opened.
+type OpenFailed struct {
+	// Path is the path that could not be opened, after any expansion.
+	Path string
+
+	// Err is the error returned by Open.
+	Err error
+}
+
+// Configured is emitted when a plugin's configuration was successfully delivered.
+type Configured struct {
+	// Path is the path of the plugin that was configured.
+	Path string
+
+	// Name is the name of the configure symbol.
+	Name string
+}
+
+// SymbolBound is emitted when a symbol was found and bound to the
+// enclosing fx.App, either as a constructor or as an invoke function.
+type SymbolBound struct {
+	// Path is the path of the plugin which exported the symbol.  This field
+	// is empty when symbols are loaded directly via Symbols.Load.
+	Path string
+
+	// Name is the symbol name.
+	Name string
+
+	// Kind describes how the symbol was bound.
+	Kind SymbolKind
+
+	// Type is the function type of the symbol.
+	Type reflect.Type
+}
+
+// SymbolMissing is emitted when a symbol could not be found in a plugin.
+type SymbolMissing struct {
+	// Path is the path of the plugin that was searched.  This field
+	// is empty when symbols are loaded directly via Symbols.Load or Lifecycle.Bind.
+	Path string
+
+	// Name is the symbol name.
+	Name string
+
+	// Ignored indicates whether the missing symbol was skipped due to
+	// IgnoreMissing.  If this field is false, the missing symbol will
+	// shortcircuit application startup.
+	Ignored bool
+}
+
+// SymbolRejected is emitted when a symbol was found but could not be used,
+// e.g. a constructor symbol that isn't a function.
+type SymbolRejected struct {
+	// Path is the path of the plugin which exported the symbol.  This field
+	// is empty when symbols are loaded directly via Symbols.Load or Lifecycle.Bind.
+	Path string
+
+	// Name is the symbol name.
+	Name string
+
+	// Err describes why the symbol was rejected.
+	Err error
+}
+
+// LifecycleBound is emitted when a plugin's symbols were bound to the
+// enclosing application's lifecycle.
+type LifecycleBound struct {
+	// Path is the path of the plugin which exported the symbols.  This field
+	// is empty when a Lifecycle is bound directly via Lifecycle.Bind.
+	Path string
+
+	// OnStart is the symbol bound as an OnStart hook.  This field is empty
+	// if no OnStart hook was bound.
+	OnStart string
+
+	// OnStop is the symbol bound as an OnStop hook.  This field is empty
+	// if no OnStop hook was bound.
+	OnStop string
+}
+
+// Skipped is emitted when an optional plugin could not be opened or bound, and
+// was left out of the enclosing fx.App instead of shortcircuiting startup.
+type Skipped struct {
+	// Path is the path of the plugin that was skipped.
+	Path string
+
+	// Err is the reason the plugin was skipped.
+	Err error
+}
+
+// Disabled is emitted when a plugin was not loaded because its Enabled condition
+// did not hold.
+type Disabled struct {
+	// Path is the configured path of the plugin, prior to any expansion.
+	Path string
+
+	// Condition describes the condition that disabled the plugin.
+	Condition string
+}
+
+// Duplicate is emitted when a plugin path resolved to a plugin file that was already
+// loaded through another path.  See Tracker.
+type Duplicate struct {
+	// Path is the duplicate path, which was not opened again.
+	Path string
+
+	// Original is the path the plugin file was first loaded through.
+	Original string
+
+	// Alias indicates that the duplicate was provided as an alias of the original plugin,
+	// rather than skipped.
+	Alias bool
+}
+
+// Logger receives pluginfx events.
+type Logger interface {
+	// LogEvent is called when a pluginfx event is emitted.
+	LogEvent(Event)
+}
+
+// NopLogger is a Logger that ignores all events.  This is the Logger
+// used when none is configured.
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) LogEvent(Event) {}
+
+func (nopLogger) String() string { return "NopLogger" }
+
+// loader holds the contextual information used when binding the symbols
+// from a particular plugin to an enclosing fx.App.
+type loader struct {
+	path      string
+	logger    Logger
+	metrics   Metrics
+	entry     *registryEntry
+	preflight bool
+
+	// timeout limits how long opening takes, unless deadline is set
+	timeout  time.Duration
+	deadline time.Time
+}
+
+func (l loader) log(e Event) {
+	if l.entry != nil {
+		l.entry.apply(e)
+	}
+
+	if l.logger != nil {
+		l.logger.LogEvent(e)
+	}
+}
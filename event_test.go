@@ -0,0 +1,202 @@
+package pluginfx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// eventRecorder is a Logger that retains each event it receives.
+type eventRecorder struct {
+	events []Event
+}
+
+func (er *eventRecorder) LogEvent(e Event) {
+	er.events = append(er.events, e)
+}
+
+type EventSuite struct {
+	PluginfxSuite
+}
+
+func (suite *EventSuite) TestP() {
+	suite.Run("Success", func() {
+		var (
+			value    float64
+			recorder eventRecorder
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Symbols: Symbols{
+						Names: []interface{}{
+							"New",
+							"Missing",
+						},
+						IgnoreMissing: true,
+					},
+					Lifecycle: Lifecycle{
+						OnStart: "Initialize",
+						OnStop:  "Shutdown",
+					},
+					Logger: &recorder,
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.Require().Len(recorder.events, 4)
+		suite.Equal(&Opened{Path: sampleOpenPath}, recorder.events[0])
+
+		bound, ok := recorder.events[1].(*SymbolBound)
+		suite.Require().True(ok)
+		suite.Equal(sampleOpenPath, bound.Path)
+		suite.Equal("New", bound.Name)
+		suite.Equal(ProvideSymbol, bound.Kind)
+
+		suite.Equal(
+			&SymbolMissing{Path: sampleOpenPath, Name: "Missing", Ignored: true},
+			recorder.events[2],
+		)
+
+		suite.Equal(
+			&LifecycleBound{Path: sampleOpenPath, OnStart: "Initialize", OnStop: "Shutdown"},
+			recorder.events[3],
+		)
+	})
+
+	suite.Run("OpenFailed", func() {
+		var recorder eventRecorder
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      "/no/such/plugin.123",
+				Logger:    &recorder,
+			}.Provide(),
+		)
+
+		suite.Error(app.Err())
+		suite.Require().Len(recorder.events, 1)
+
+		failed, ok := recorder.events[0].(*OpenFailed)
+		suite.Require().True(ok)
+		suite.Equal("/no/such/plugin.123", failed.Path)
+		suite.openError("/no/such/plugin.123", failed.Err)
+	})
+}
+
+func (suite *EventSuite) TestS() {
+	var (
+		recorder eventRecorder
+		value    float64
+
+		app = fxtest.New(
+			suite.T(),
+			S{
+				Paths: []string{samplePath},
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+				Logger: &recorder,
+			}.Provide(),
+			fx.Populate(&value),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.Require().Len(recorder.events, 2)
+	suite.Equal(&Opened{Path: sampleOpenPath}, recorder.events[0])
+	suite.IsType((*SymbolBound)(nil), recorder.events[1])
+}
+
+func (suite *EventSuite) TestSymbolsLoad() {
+	var (
+		recorder eventRecorder
+		s        = Symbols{
+			Logger: &recorder,
+			Names: []interface{}{
+				"Invoke",
+				"NotAFunction",
+				Annotated{Name: "value", Target: "Target"},
+				Annotated{Name: "invalid", Target: "Invoke"},
+				123,
+			},
+		}
+
+		sm = NewSymbols(
+			"Invoke", func() {},
+			"NotAFunction", 123,
+			"Target", func() int { return 1 },
+		)
+	)
+
+	s.Load(sm)
+	suite.Require().Len(recorder.events, 5)
+
+	suite.Require().IsType((*SymbolBound)(nil), recorder.events[0])
+	suite.Equal(InvokeSymbol, recorder.events[0].(*SymbolBound).Kind)
+
+	suite.Require().IsType((*SymbolRejected)(nil), recorder.events[1])
+	suite.Equal("NotAFunction", recorder.events[1].(*SymbolRejected).Name)
+
+	suite.Require().IsType((*SymbolBound)(nil), recorder.events[2])
+	suite.Equal(TargetSymbol, recorder.events[2].(*SymbolBound).Kind)
+
+	suite.Require().IsType((*SymbolRejected)(nil), recorder.events[3])
+	var ite *InvalidTargetError
+	suite.ErrorAs(recorder.events[3].(*SymbolRejected).Err, &ite)
+
+	suite.Require().IsType((*SymbolRejected)(nil), recorder.events[4])
+	suite.Equal("123", recorder.events[4].(*SymbolRejected).Name)
+}
+
+func (suite *EventSuite) TestLifecycleBind() {
+	var (
+		recorder eventRecorder
+		lc       = Lifecycle{
+			OnStart: "NotAFunction",
+			OnStop:  "Missing",
+			Logger:  &recorder,
+		}
+	)
+
+	lc.Bind(NewSymbols("NotAFunction", 123))
+	suite.Require().Len(recorder.events, 2)
+
+	suite.Require().IsType((*SymbolRejected)(nil), recorder.events[0])
+	var ile *InvalidLifecycleError
+	suite.ErrorAs(recorder.events[0].(*SymbolRejected).Err, &ile)
+
+	suite.Equal(&SymbolMissing{Name: "Missing"}, recorder.events[1])
+}
+
+func (suite *EventSuite) TestSymbolKind() {
+	suite.Equal("PROVIDE", ProvideSymbol.String())
+	suite.Equal("INVOKE", InvokeSymbol.String())
+	suite.Equal("TARGET", TargetSymbol.String())
+	suite.Equal("ONSTART", OnStartSymbol.String())
+	suite.Equal("ONSTOP", OnStopSymbol.String())
+	suite.Equal("CHECK", CheckSymbol.String())
+	suite.Equal("UNKNOWN", SymbolKind(-1).String())
+}
+
+func (suite *EventSuite) TestNopLogger() {
+	suite.NotPanics(func() {
+		NopLogger.LogEvent(&Opened{})
+	})
+
+	suite.Equal("NopLogger", NopLogger.(nopLogger).String())
+}
+
+func TestEvent(t *testing.T) {
+	suite.Run(t, new(EventSuite))
+}
@@ -0,0 +1,257 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrUndefinedVariable indicates that a strict ShellExpander encountered
+	// a variable that was not defined and had no default.
+	ErrUndefinedVariable = errors.New("Undefined variable")
+
+	// ErrBadExpansion indicates that a path contained malformed variable syntax,
+	// such as an unterminated ${.
+	ErrBadExpansion = errors.New("Bad variable expansion")
+)
+
+// ExpandError indicates that variables in a plugin path could not be expanded.
+type ExpandError struct {
+	// Path is the original, unexpanded path.
+	Path string
+
+	// Variable is the name of the variable that could not be expanded.  This field
+	// is empty if the path was malformed.
+	Variable string
+
+	// Err describes the problem.
+	Err error
+}
+
+func (ee *ExpandError) Unwrap() error {
+	return ee.Err
+}
+
+func (ee *ExpandError) Error() string {
+	if len(ee.Variable) > 0 {
+		return fmt.Sprintf("Unable to expand variable %s in path %s: %s", ee.Variable, ee.Path, ee.Err)
+	}
+
+	return fmt.Sprintf("Unable to expand path %s: %s", ee.Path, ee.Err)
+}
+
+// Expander performs variable expansion on plugin paths.
+type Expander interface {
+	// Expand returns the result of expanding variables in the given path.
+	Expand(string) (string, error)
+}
+
+// ExpanderFunc is a function type that implements Expander.
+type ExpanderFunc func(string) (string, error)
+
+// Expand invokes this function.
+func (ef ExpanderFunc) Expand(path string) (string, error) {
+	return ef(path)
+}
+
+// ShellExpander is an Expander that supports a subset of POSIX shell parameter expansion:
+//
+//   - $VAR and ${VAR} expand to the value of VAR
+//   - ${VAR:-default} expands to default if VAR is undefined or empty
+//   - ${VAR-default} expands to default if VAR is undefined
+//   - ${VAR:?message} is an error if VAR is undefined or empty
+//   - ${VAR?message} is an error if VAR is undefined
+//   - $$ expands to a literal $
+//
+// Default values may themselves contain variables.  A $ that is not followed by
+// a variable name or a { is left as is.
+//
+// The zero value of this type behaves like os.ExpandEnv, with the addition of the syntax above.
+type ShellExpander struct {
+	// Lookup is the optional source of variable values.  If unset, os.LookupEnv is used.
+	Lookup func(string) (string, bool)
+
+	// Strict controls what happens when a variable is undefined and has no default.
+	// If this field is true, an *ExpandError wrapping ErrUndefinedVariable is returned.
+	// Otherwise, undefined variables expand to the empty string.
+	Strict bool
+}
+
+var _ Expander = ShellExpander{}
+
+func (se ShellExpander) lookup(name string) (string, bool) {
+	if se.Lookup != nil {
+		return se.Lookup(name)
+	}
+
+	return os.LookupEnv(name)
+}
+
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (!first && '0' <= c && c <= '9')
+}
+
+// closingBrace returns the index of the } that matches a ${ which starts at the beginning of s.
+// This function returns -1 if there is no matching brace.
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Expand implements Expander.
+func (se ShellExpander) Expand(path string) (string, error) {
+	var (
+		o     strings.Builder
+		value string
+		err   error
+	)
+
+	for i := 0; i < len(path); {
+		if path[i] != '$' || i+1 >= len(path) {
+			o.WriteByte(path[i])
+			i++
+			continue
+		}
+
+		switch next := path[i+1]; {
+		case next == '$':
+			o.WriteByte('$')
+			i += 2
+
+		case next == '{':
+			end := closingBrace(path[i:])
+			if end < 0 {
+				return "", &ExpandError{Path: path, Err: ErrBadExpansion}
+			}
+
+			value, err = se.expandBraced(path, path[i+2:i+end])
+			if err != nil {
+				return "", err
+			}
+
+			o.WriteString(value)
+			i += end + 1
+
+		case isNameChar(next, true):
+			j := i + 2
+			for j < len(path) && isNameChar(path[j], false) {
+				j++
+			}
+
+			value, err = se.expandName(path, path[i+1:j])
+			if err != nil {
+				return "", err
+			}
+
+			o.WriteString(value)
+			i = j
+
+		default:
+			o.WriteByte('$')
+			i++
+		}
+	}
+
+	return o.String(), nil
+}
+
+// expandName expands a simple variable reference with no operator.
+func (se ShellExpander) expandName(path, name string) (string, error) {
+	value, ok := se.lookup(name)
+	if !ok && se.Strict {
+		return "", &ExpandError{Path: path, Variable: name, Err: ErrUndefinedVariable}
+	}
+
+	return value, nil
+}
+
+// expandWord expands a word nested within ${...}, reporting any error against the original path.
+func (se ShellExpander) expandWord(path, word string) (string, error) {
+	value, err := se.Expand(word)
+	var ee *ExpandError
+	if errors.As(err, &ee) {
+		ee.Path = path
+	}
+
+	return value, err
+}
+
+// expandBraced expands the contents of ${...}.
+func (se ShellExpander) expandBraced(path, expr string) (string, error) {
+	n := 0
+	for n < len(expr) && isNameChar(expr[n], n == 0) {
+		n++
+	}
+
+	if n == 0 {
+		return "", &ExpandError{Path: path, Err: ErrBadExpansion}
+	}
+
+	var (
+		name      = expr[:n]
+		operator  = expr[n:]
+		word      string
+		checkNull bool
+	)
+
+	if len(operator) == 0 {
+		return se.expandName(path, name)
+	}
+
+	if operator[0] == ':' {
+		checkNull = true
+		operator = operator[1:]
+	}
+
+	if len(operator) == 0 {
+		return "", &ExpandError{Path: path, Variable: name, Err: ErrBadExpansion}
+	}
+
+	word = operator[1:]
+	value, ok := se.lookup(name)
+	unset := !ok || (checkNull && len(value) == 0)
+
+	switch operator[0] {
+	case '-':
+		if unset {
+			return se.expandWord(path, word)
+		}
+
+		return value, nil
+
+	case '?':
+		if unset {
+			message, err := se.expandWord(path, word)
+			if err != nil {
+				return "", err
+			}
+
+			if len(message) == 0 {
+				message = "parameter null or not set"
+			}
+
+			return "", &ExpandError{Path: path, Variable: name, Err: errors.New(message)}
+		}
+
+		return value, nil
+
+	default:
+		return "", &ExpandError{Path: path, Variable: name, Err: ErrBadExpansion}
+	}
+}
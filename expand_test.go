@@ -0,0 +1,182 @@
+package pluginfx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type ExpandSuite struct {
+	suite.Suite
+}
+
+func (suite *ExpandSuite) lookup(name string) (string, bool) {
+	switch name {
+	case "DIR":
+		return "/opt/plugins", true
+
+	case "EMPTY":
+		return "", true
+
+	case "NAME":
+		return "sample", true
+
+	default:
+		return "", false
+	}
+}
+
+func (suite *ExpandSuite) TestExpanderFunc() {
+	ef := ExpanderFunc(func(path string) (string, error) {
+		return path + ".so", nil
+	})
+
+	path, err := ef.Expand("test")
+	suite.NoError(err)
+	suite.Equal("test.so", path)
+}
+
+func (suite *ExpandSuite) TestShellExpander() {
+	testCases := []struct {
+		path     string
+		strict   bool
+		expected string
+	}{
+		{path: "", expected: ""},
+		{path: "/no/variables.so", expected: "/no/variables.so"},
+		{path: "$DIR/$NAME.so", expected: "/opt/plugins/sample.so"},
+		{path: "${DIR}/${NAME}.so", expected: "/opt/plugins/sample.so"},
+		{path: "$MISSING/plugin.so", expected: "/plugin.so"},
+		{path: "${MISSING:-/usr/lib}/plugin.so", expected: "/usr/lib/plugin.so"},
+		{path: "${EMPTY:-/usr/lib}/plugin.so", expected: "/usr/lib/plugin.so"},
+		{path: "${EMPTY-/usr/lib}/plugin.so", expected: "/plugin.so"},
+		{path: "${MISSING-/usr/lib}/plugin.so", expected: "/usr/lib/plugin.so"},
+		{path: "${DIR:-/usr/lib}/plugin.so", expected: "/opt/plugins/plugin.so"},
+		{path: "${MISSING:-${DIR}}/plugin.so", expected: "/opt/plugins/plugin.so"},
+		{path: "${DIR:?must be set}/plugin.so", expected: "/opt/plugins/plugin.so"},
+		{path: "${EMPTY?must be set}/plugin.so", expected: "/plugin.so"},
+		{path: "$$DIR/plugin.so", expected: "$DIR/plugin.so"},
+		{path: "/price/$5/plugin.so", expected: "/price/$5/plugin.so"},
+		{path: "/trailing$", expected: "/trailing$"},
+		{path: "${MISSING:-/usr/lib}/plugin.so", strict: true, expected: "/usr/lib/plugin.so"},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.path, func() {
+			se := ShellExpander{
+				Lookup: suite.lookup,
+				Strict: testCase.strict,
+			}
+
+			actual, err := se.Expand(testCase.path)
+			suite.NoError(err)
+			suite.Equal(testCase.expected, actual)
+		})
+	}
+}
+
+func (suite *ExpandSuite) TestShellExpanderErrors() {
+	testCases := []struct {
+		path     string
+		strict   bool
+		variable string
+		err      error
+	}{
+		{path: "$MISSING/plugin.so", strict: true, variable: "MISSING", err: ErrUndefinedVariable},
+		{path: "${MISSING}/plugin.so", strict: true, variable: "MISSING", err: ErrUndefinedVariable},
+		{path: "${MISSING:-$ALSO_MISSING}/plugin.so", strict: true, variable: "ALSO_MISSING", err: ErrUndefinedVariable},
+		{path: "${MISSING:?}/plugin.so", variable: "MISSING"},
+		{path: "${EMPTY:?must be set}/plugin.so", variable: "EMPTY"},
+		{path: "${MISSING?must be set}/plugin.so", variable: "MISSING"},
+		{path: "${MISSING:?${ALSO_MISSING}}/plugin.so", strict: true, variable: "ALSO_MISSING", err: ErrUndefinedVariable},
+		{path: "${DIR/plugin.so", err: ErrBadExpansion},
+		{path: "${}/plugin.so", err: ErrBadExpansion},
+		{path: "${DIR:}/plugin.so", variable: "DIR", err: ErrBadExpansion},
+		{path: "${DIR+alternate}/plugin.so", variable: "DIR", err: ErrBadExpansion},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.path, func() {
+			se := ShellExpander{
+				Lookup: suite.lookup,
+				Strict: testCase.strict,
+			}
+
+			actual, err := se.Expand(testCase.path)
+			suite.Empty(actual)
+
+			var ee *ExpandError
+			suite.Require().True(errors.As(err, &ee))
+			suite.Equal(testCase.path, ee.Path)
+			suite.Equal(testCase.variable, ee.Variable)
+			suite.NotEmpty(ee.Error())
+			if testCase.err != nil {
+				suite.ErrorIs(err, testCase.err)
+			}
+		})
+	}
+}
+
+func (suite *ExpandSuite) TestDefaultLookup() {
+	suite.T().Setenv("PLUGINFX_TEST_DIR", "/from/env")
+	actual, err := ShellExpander{}.Expand("$PLUGINFX_TEST_DIR/plugin.so")
+	suite.NoError(err)
+	suite.Equal("/from/env/plugin.so", actual)
+}
+
+func (suite *ExpandSuite) TestP() {
+	app := fx.New(
+		P{
+			Path:     "${PLUGINFX_NO_SUCH_VARIABLE}/plugin.so",
+			Expander: ShellExpander{Strict: true},
+		}.Provide(),
+	)
+
+	err := app.Err()
+	suite.Require().Error(err)
+
+	var pe *PluginError
+	suite.Require().True(errors.As(err, &pe))
+	suite.Equal("${PLUGINFX_NO_SUCH_VARIABLE}/plugin.so", pe.Path)
+	suite.Equal(PhaseOpen, pe.Phase)
+	suite.ErrorIs(err, ErrUndefinedVariable)
+}
+
+func (suite *ExpandSuite) TestS() {
+	suite.Run("Error", func() {
+		app := fx.New(
+			S{
+				Paths:    []string{"${PLUGINFX_NO_SUCH_VARIABLE:?plugin directory required}/*.so"},
+				Expander: ShellExpander{},
+			}.Provide(),
+		)
+
+		err := app.Err()
+		suite.Require().Error(err)
+
+		var ee *ExpandError
+		suite.Require().True(errors.As(err, &ee))
+		suite.Equal("PLUGINFX_NO_SUCH_VARIABLE", ee.Variable)
+		suite.Contains(ee.Error(), "plugin directory required")
+	})
+
+	suite.Run("Optional", func() {
+		var skipped []error
+		app := fx.New(
+			S{
+				Paths:    []string{"${PLUGINFX_NO_SUCH_VARIABLE:?required}/*.so"},
+				Optional: true,
+				OnError:  func(err error) { skipped = append(skipped, err) },
+			}.Provide(),
+		)
+
+		suite.NoError(app.Err())
+		suite.Len(skipped, 1)
+	})
+}
+
+func TestExpand(t *testing.T) {
+	suite.Run(t, new(ExpandSuite))
+}
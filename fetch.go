@@ -0,0 +1,273 @@
+package pluginfx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedDigest indicates that an expected digest was not in a supported format.
+var ErrUnsupportedDigest = errors.New("A digest must be a hex-encoded SHA-256, optionally prefixed with sha256:")
+
+// isURL tests if a plugin path is a URL that must be fetched rather than a filesystem path.
+func isURL(p string) bool {
+	return strings.HasPrefix(p, "http://") ||
+		strings.HasPrefix(p, "https://") ||
+		strings.HasPrefix(p, "file://")
+}
+
+// FetchError indicates that a plugin could not be downloaded.  A FetchError for a 404
+// response satisfies errors.Is(err, fs.ErrNotExist).
+type FetchError struct {
+	// URL is the plugin URL.
+	URL string
+
+	// StatusCode is the HTTP status code of the response.  This field is zero if
+	// no response was received.
+	StatusCode int
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (fe *FetchError) Unwrap() error {
+	if fe.StatusCode == http.StatusNotFound {
+		return fs.ErrNotExist
+	}
+
+	return fe.Err
+}
+
+func (fe *FetchError) Error() string {
+	if fe.Err != nil {
+		return fmt.Sprintf("Unable to fetch plugin from %s: %s", fe.URL, fe.Err)
+	}
+
+	return fmt.Sprintf("Unable to fetch plugin from %s: %d %s", fe.URL, fe.StatusCode, http.StatusText(fe.StatusCode))
+}
+
+// DigestError indicates that a plugin's content did not match its expected digest.
+type DigestError struct {
+	// URL is the plugin URL.
+	URL string
+
+	// Expected is the expected SHA-256, hex-encoded.
+	Expected string
+
+	// Actual is the SHA-256 of the content that was received, hex-encoded.
+	Actual string
+}
+
+func (de *DigestError) Error() string {
+	return fmt.Sprintf("Plugin from %s has digest sha256:%s, expected sha256:%s", de.URL, de.Actual, de.Expected)
+}
+
+// parseDigest normalizes an expected digest to lowercase hex.  An empty digest is allowed,
+// and means the content is not verified.
+func parseDigest(digest string) (string, error) {
+	digest = strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+	if len(digest) == 0 {
+		return "", nil
+	}
+
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+		return "", ErrUnsupportedDigest
+	}
+
+	return digest, nil
+}
+
+// fetchMetadata is the sidecar stored next to downloaded plugins, used for conditional GETs.
+type fetchMetadata struct {
+	URL          string `json:"url"`
+	File         string `json:"file"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// fetcher downloads plugins into a cache directory.
+type fetcher struct {
+	client *http.Client
+	dir    string
+}
+
+// newFetcher creates a fetcher with the given optional client and cache directory.
+func newFetcher(client *http.Client, dir string) fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if len(dir) == 0 {
+		dir = DefaultCacheDir()
+	}
+
+	return fetcher{
+		client: client,
+		dir:    dir,
+	}
+}
+
+// metadataPath returns the location of the sidecar for a URL.
+func (f fetcher) metadataPath(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readMetadata returns the sidecar for a URL if the cached file it refers to still
+// exists and matches the expected digest.
+func (f fetcher) readMetadata(u, digest string) (m fetchMetadata, ok bool) {
+	data, err := os.ReadFile(f.metadataPath(u))
+	if err != nil || json.Unmarshal(data, &m) != nil || m.URL != u || len(m.File) == 0 {
+		return
+	}
+
+	// cached files are named by their SHA-256
+	name := filepath.Base(m.File)
+	if len(digest) > 0 && !strings.HasPrefix(name, digest) {
+		return
+	}
+
+	_, err = os.Stat(m.File)
+	ok = err == nil
+	return
+}
+
+// verifier returns the function that checks downloaded content against a digest.
+func verifier(u, digest string) func([]byte) error {
+	if len(digest) == 0 {
+		return nil
+	}
+
+	return func(sum []byte) error {
+		if actual := hex.EncodeToString(sum); actual != digest {
+			return &DigestError{
+				URL:      u,
+				Expected: digest,
+				Actual:   actual,
+			}
+		}
+
+		return nil
+	}
+}
+
+// fetch returns the local path for a plugin URL, downloading it if necessary.  The returned
+// event describes where the plugin came from.
+func (f fetcher) fetch(u, digest string) (*Fetched, error) {
+	digest, err := parseDigest(digest)
+	if err != nil {
+		return nil, &FetchError{URL: u, Err: err}
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, &FetchError{URL: u, Err: err}
+	}
+
+	if parsed.Scheme == "file" {
+		return f.fetchFile(u, parsed.Path, digest)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, &FetchError{URL: u, Err: err}
+	}
+
+	m, cached := f.readMetadata(u, digest)
+	if cached {
+		if len(m.ETag) > 0 {
+			request.Header.Set("If-None-Match", m.ETag)
+		}
+
+		if len(m.LastModified) > 0 {
+			request.Header.Set("If-Modified-Since", m.LastModified)
+		}
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, &FetchError{URL: u, Err: err}
+	}
+
+	defer response.Body.Close()
+	switch {
+	case cached && response.StatusCode == http.StatusNotModified:
+		return &Fetched{URL: u, Path: m.File, Cached: true}, nil
+
+	case response.StatusCode != http.StatusOK:
+		io.Copy(io.Discard, response.Body)
+		return nil, &FetchError{URL: u, StatusCode: response.StatusCode}
+	}
+
+	file, err := store(f.dir, path.Ext(parsed.Path), response.Body, verifier(u, digest))
+	if err != nil {
+		var de *DigestError
+		if !errors.As(err, &de) {
+			err = &FetchError{URL: u, StatusCode: response.StatusCode, Err: err}
+		}
+
+		return nil, err
+	}
+
+	m = fetchMetadata{
+		URL:          u,
+		File:         file,
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+	}
+
+	// the sidecar only enables conditional requests, so failing to write it is not fatal
+	if data, err := json.Marshal(m); err == nil {
+		os.WriteFile(f.metadataPath(u), data, 0600)
+	}
+
+	return &Fetched{URL: u, Path: file}, nil
+}
+
+// fetchFile handles a file URL, which is opened in place after verifying its digest.
+func (f fetcher) fetchFile(u, p, digest string) (*Fetched, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, &FetchError{URL: u, Err: err}
+	}
+
+	defer file.Close()
+	if verify := verifier(u, digest); verify != nil {
+		h := sha256.New()
+		if _, err := io.Copy(h, file); err != nil {
+			return nil, &FetchError{URL: u, Err: err}
+		}
+
+		if err := verify(h.Sum(nil)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Fetched{URL: u, Path: p, Cached: true}, nil
+}
+
+// fetchFirst fetches the first of several candidate URLs that exists.  If none exist,
+// a *CandidateError is returned.
+func (f fetcher) fetchFirst(configured string, candidates []string, digest string) (*Fetched, error) {
+	for _, c := range candidates {
+		fetched, err := f.fetch(c, digest)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) || len(candidates) == 1 {
+			return fetched, err
+		}
+	}
+
+	return nil, &CandidateError{
+		Path:       configured,
+		Candidates: candidates,
+	}
+}
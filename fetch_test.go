@@ -0,0 +1,370 @@
+package pluginfx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// artifactServer is a stand-in for an artifact repository.  It serves a fixed set of
+// files, each with an ETag, and honors If-None-Match.
+type artifactServer struct {
+	lock        sync.Mutex
+	files       map[string][]byte
+	requests    []*http.Request
+	notModified int
+}
+
+func (as *artifactServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	as.requests = append(as.requests, request)
+	data, ok := as.files[request.URL.Path]
+	switch {
+	case request.URL.Path == "/error":
+		response.WriteHeader(http.StatusInternalServerError)
+
+	case !ok:
+		response.WriteHeader(http.StatusNotFound)
+
+	default:
+		sum := sha256.Sum256(data)
+		etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+		if request.Header.Get("If-None-Match") == etag {
+			as.notModified++
+			response.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		response.Header().Set("ETag", etag)
+		response.Write(data)
+	}
+}
+
+type FetchSuite struct {
+	PluginfxSuite
+
+	artifacts *artifactServer
+	server    *httptest.Server
+}
+
+func (suite *FetchSuite) SetupTest() {
+	embedded, err := os.ReadFile(embeddedPath)
+	suite.Require().NoError(err)
+
+	suite.artifacts = &artifactServer{
+		files: map[string][]byte{
+			"/plugins/test.so":     []byte("test plugin content"),
+			"/plugins/embedded.so": embedded,
+		},
+	}
+
+	suite.server = httptest.NewServer(suite.artifacts)
+}
+
+func (suite *FetchSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *FetchSuite) url(p string) string {
+	return suite.server.URL + p
+}
+
+func (suite *FetchSuite) digest(p string) string {
+	sum := sha256.Sum256(suite.artifacts.files[p])
+	return hex.EncodeToString(sum[:])
+}
+
+func (suite *FetchSuite) TestParseDigest() {
+	d := suite.digest("/plugins/test.so")
+
+	actual, err := parseDigest("")
+	suite.NoError(err)
+	suite.Empty(actual)
+
+	actual, err = parseDigest(d)
+	suite.NoError(err)
+	suite.Equal(d, actual)
+
+	actual, err = parseDigest("sha256:" + d)
+	suite.NoError(err)
+	suite.Equal(d, actual)
+
+	_, err = parseDigest("md5:abcdef")
+	suite.ErrorIs(err, ErrUnsupportedDigest)
+
+	_, err = parseDigest("sha256:abcd")
+	suite.ErrorIs(err, ErrUnsupportedDigest)
+}
+
+func (suite *FetchSuite) TestConditionalGet() {
+	var (
+		dir = suite.T().TempDir()
+		f   = newFetcher(suite.server.Client(), dir)
+		u   = suite.url("/plugins/test.so")
+	)
+
+	first, err := f.fetch(u, "sha256:"+suite.digest("/plugins/test.so"))
+	suite.Require().NoError(err)
+	suite.False(first.Cached)
+	suite.Equal(u, first.URL)
+	suite.Equal(filepath.Join(dir, suite.digest("/plugins/test.so")+".so"), first.Path)
+
+	data, err := os.ReadFile(first.Path)
+	suite.NoError(err)
+	suite.Equal(suite.artifacts.files["/plugins/test.so"], data)
+
+	second, err := f.fetch(u, "")
+	suite.Require().NoError(err)
+	suite.True(second.Cached)
+	suite.Equal(first.Path, second.Path)
+
+	suite.Require().Len(suite.artifacts.requests, 2)
+	suite.Empty(suite.artifacts.requests[0].Header.Get("If-None-Match"))
+	suite.NotEmpty(suite.artifacts.requests[1].Header.Get("If-None-Match"))
+	suite.Equal(1, suite.artifacts.notModified)
+
+	suite.Run("CachedCopyRemoved", func() {
+		suite.Require().NoError(os.Remove(first.Path))
+		third, err := f.fetch(u, "")
+		suite.Require().NoError(err)
+		suite.False(third.Cached)
+		suite.FileExists(third.Path)
+		suite.Empty(suite.artifacts.requests[2].Header.Get("If-None-Match"))
+	})
+}
+
+func (suite *FetchSuite) TestDigestMismatch() {
+	var (
+		dir   = suite.T().TempDir()
+		f     = newFetcher(suite.server.Client(), dir)
+		u     = suite.url("/plugins/test.so")
+		wrong = suite.digest("/plugins/embedded.so")
+	)
+
+	fetched, err := f.fetch(u, wrong)
+	suite.Nil(fetched)
+
+	var de *DigestError
+	suite.Require().True(errors.As(err, &de))
+	suite.Equal(u, de.URL)
+	suite.Equal(wrong, de.Expected)
+	suite.Equal(suite.digest("/plugins/test.so"), de.Actual)
+	suite.NotEmpty(de.Error())
+
+	entries, err := os.ReadDir(dir)
+	suite.NoError(err)
+	suite.Empty(entries)
+
+	suite.Run("CachedCopyIgnored", func() {
+		_, err := f.fetch(u, "")
+		suite.Require().NoError(err)
+
+		// a cached copy that doesn't match the digest must not be used via a conditional request
+		_, err = f.fetch(u, wrong)
+		suite.Require().True(errors.As(err, &de))
+		suite.Empty(suite.artifacts.requests[len(suite.artifacts.requests)-1].Header.Get("If-None-Match"))
+	})
+}
+
+func (suite *FetchSuite) TestErrors() {
+	f := newFetcher(suite.server.Client(), suite.T().TempDir())
+
+	suite.Run("NotFound", func() {
+		_, err := f.fetch(suite.url("/nosuch.so"), "")
+		suite.ErrorIs(err, fs.ErrNotExist)
+
+		var fe *FetchError
+		suite.Require().True(errors.As(err, &fe))
+		suite.Equal(http.StatusNotFound, fe.StatusCode)
+		suite.Contains(fe.Error(), "404")
+	})
+
+	suite.Run("ServerError", func() {
+		_, err := f.fetch(suite.url("/error"), "")
+		suite.False(errors.Is(err, fs.ErrNotExist))
+
+		var fe *FetchError
+		suite.Require().True(errors.As(err, &fe))
+		suite.Equal(http.StatusInternalServerError, fe.StatusCode)
+	})
+
+	suite.Run("BadDigest", func() {
+		_, err := f.fetch(suite.url("/plugins/test.so"), "nothex")
+		suite.ErrorIs(err, ErrUnsupportedDigest)
+	})
+
+	suite.Run("NoServer", func() {
+		_, err := f.fetch("http://127.0.0.1:0/test.so", "")
+
+		var fe *FetchError
+		suite.Require().True(errors.As(err, &fe))
+		suite.Zero(fe.StatusCode)
+		suite.Error(fe.Err)
+	})
+}
+
+func (suite *FetchSuite) TestFetchFirst() {
+	f := newFetcher(suite.server.Client(), suite.T().TempDir())
+
+	fetched, err := f.fetchFirst("configured", []string{suite.url("/nosuch.so"), suite.url("/plugins/test.so")}, "")
+	suite.Require().NoError(err)
+	suite.Equal(suite.url("/plugins/test.so"), fetched.URL)
+
+	_, err = f.fetchFirst("configured", []string{suite.url("/nosuch.so"), suite.url("/alsonosuch.so")}, "")
+	var ce *CandidateError
+	suite.Require().True(errors.As(err, &ce))
+	suite.Equal("configured", ce.Path)
+	suite.Len(ce.Candidates, 2)
+
+	_, err = f.fetchFirst("configured", []string{suite.url("/error"), suite.url("/plugins/test.so")}, "")
+	var fe *FetchError
+	suite.Require().True(errors.As(err, &fe))
+}
+
+func (suite *FetchSuite) TestFileURL() {
+	var (
+		path = filepath.Join(suite.T().TempDir(), "test.so")
+		f    = newFetcher(nil, suite.T().TempDir())
+	)
+
+	suite.Require().NoError(os.WriteFile(path, suite.artifacts.files["/plugins/test.so"], 0600))
+
+	fetched, err := f.fetch("file://"+path, suite.digest("/plugins/test.so"))
+	suite.Require().NoError(err)
+	suite.Equal(path, fetched.Path)
+	suite.True(fetched.Cached)
+
+	_, err = f.fetch("file://"+path, suite.digest("/plugins/embedded.so"))
+	var de *DigestError
+	suite.True(errors.As(err, &de))
+
+	_, err = f.fetch("file://"+path+".nosuch", "")
+	suite.ErrorIs(err, fs.ErrNotExist)
+}
+
+func (suite *FetchSuite) TestP() {
+	var (
+		recorder eventRecorder
+		name     string
+		registry *Registry
+
+		u = suite.url("/plugins/embedded.so")
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Anonymous:  true,
+				Path:       u,
+				Digest:     suite.digest("/plugins/embedded.so"),
+				CacheDir:   testCacheDir,
+				HTTPClient: suite.server.Client(),
+				Logger:     &recorder,
+				Symbols: Symbols{
+					Names: []interface{}{"Name"},
+				},
+			}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&name, &registry),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Equal("embedded", name)
+
+	cached := filepath.Join(testCacheDir, suite.digest("/plugins/embedded.so")+".so")
+	suite.Require().NotEmpty(recorder.events)
+	fetched, ok := recorder.events[0].(*Fetched)
+	suite.Require().True(ok)
+	suite.Equal(u, fetched.URL)
+	suite.Equal(cached, fetched.Path)
+
+	record, ok := registry.Get(u)
+	suite.Require().True(ok)
+	suite.Equal(cached, record.ExpandedPath)
+
+	suite.Run("DigestMismatch", func() {
+		app := fx.New(
+			P{
+				Anonymous:  true,
+				Path:       u,
+				Digest:     suite.digest("/plugins/test.so"),
+				CacheDir:   testCacheDir,
+				HTTPClient: suite.server.Client(),
+			}.Provide(),
+		)
+
+		var de *DigestError
+		suite.Require().True(errors.As(app.Err(), &de))
+
+		var pe *PluginError
+		suite.Require().True(errors.As(app.Err(), &pe))
+		suite.Equal(u, pe.Path)
+		suite.Equal(PhaseOpen, pe.Phase)
+	})
+}
+
+func (suite *FetchSuite) TestS() {
+	var (
+		recorder eventRecorder
+		plugins  []Plugin
+
+		u = suite.url("/plugins/embedded.so")
+
+		app = fxtest.New(
+			suite.T(),
+			S{
+				Group:      "plugins",
+				Paths:      []string{u},
+				Digests:    map[string]string{u: suite.digest("/plugins/embedded.so")},
+				CacheDir:   testCacheDir,
+				HTTPClient: suite.server.Client(),
+				Logger:     &recorder,
+			}.Provide(),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					Plugins []Plugin `group:"plugins"`
+				}) {
+					plugins = in.Plugins
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Len(plugins, 1)
+
+	suite.Require().NotEmpty(recorder.events)
+	suite.IsType((*Fetched)(nil), recorder.events[0])
+
+	suite.Run("NotFound", func() {
+		app := fx.New(
+			S{
+				Paths:      []string{suite.url("/nosuch.so")},
+				CacheDir:   testCacheDir,
+				HTTPClient: suite.server.Client(),
+			}.Provide(),
+		)
+
+		suite.ErrorIs(app.Err(), fs.ErrNotExist)
+	})
+}
+
+func TestFetch(t *testing.T) {
+	suite.Run(t, new(FetchSuite))
+}
@@ -0,0 +1,151 @@
+package pluginfx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"go.uber.org/fx"
+)
+
+// DefaultCacheDir returns the directory that plugin files sourced from an fs.FS or a URL are
+// materialized into when no CacheDir is configured.  This is a pluginfx subdirectory
+// of os.UserCacheDir, or of os.TempDir if there is no user cache directory.
+func DefaultCacheDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+
+	return filepath.Join(base, "pluginfx")
+}
+
+// store writes content into dir, naming the file after the SHA-256 of that content plus
+// the given extension.  If a file with that name already exists, it is reused.  The directory
+// is created, readable only by the current user, if necessary.
+//
+// If verify is supplied, it is passed the SHA-256 of the content before the file is stored.
+// Any error from verify prevents the file from being stored.
+func store(dir, ext string, content io.Reader, verify func([]byte) error) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	// write to a temporary file first, so that a partially written plugin is never reused
+	f, err := os.CreateTemp(dir, ".pluginfx-*")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(f.Name())
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	sum := h.Sum(nil)
+	if err == nil && verify != nil {
+		err = verify(sum)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(dir, hex.EncodeToString(sum)+ext)
+	if _, err := os.Stat(target); err == nil {
+		return target, nil
+	}
+
+	if err := os.Chmod(f.Name(), 0500); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(f.Name(), target); err != nil {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// materialize copies a file from fsys into dir via store.
+func materialize(fsys fs.FS, name, dir string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+	return store(dir, path.Ext(name), f, nil)
+}
+
+// fsSource describes where plugin files come from when they are sourced from an fs.FS.
+type fsSource struct {
+	fsys    fs.FS
+	dir     string
+	cleanup bool
+}
+
+// newFSSource creates an fsSource.  If fsys is nil, this function returns nil, meaning
+// that plugins are opened directly from the filesystem.
+func newFSSource(fsys fs.FS, dir string, cleanup bool) *fsSource {
+	if fsys == nil {
+		return nil
+	}
+
+	if len(dir) == 0 {
+		dir = DefaultCacheDir()
+	}
+
+	return &fsSource{
+		fsys:    fsys,
+		dir:     dir,
+		cleanup: cleanup,
+	}
+}
+
+// stat examines a candidate within the fs.FS.
+func (src *fsSource) stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(src.fsys, name)
+}
+
+// glob matches a pattern within the fs.FS.
+func (src *fsSource) glob(pattern string) ([]string, error) {
+	return fs.Glob(src.fsys, pattern)
+}
+
+// materialize copies the named file into the cache directory, returning the
+// path it should be opened from.
+func (src *fsSource) materialize(name string) (string, error) {
+	return materialize(src.fsys, name, src.dir)
+}
+
+// cleanupOption returns the option that removes a materialized file when the
+// enclosing fx.App stops, if cleanup was requested.
+func (src *fsSource) cleanupOption(path string) fx.Option {
+	if !src.cleanup {
+		return fx.Options()
+	}
+
+	return fx.Invoke(
+		func(l fx.Lifecycle) {
+			l.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+						return err
+					}
+
+					return nil
+				},
+			})
+		},
+	)
+}
@@ -0,0 +1,238 @@
+package pluginfx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type FSSuite struct {
+	PluginfxSuite
+}
+
+// embeddedFS returns an in-memory file system that contains the embedded plugin,
+// which is analogous to an embed.FS.
+func (suite *FSSuite) embeddedFS(name string) (fstest.MapFS, string) {
+	data, err := os.ReadFile(embeddedPath)
+	suite.Require().NoError(err)
+
+	sum := sha256.Sum256(data)
+	return fstest.MapFS{
+		name: &fstest.MapFile{Data: data, Mode: 0644},
+	}, filepath.Join(testCacheDir, hex.EncodeToString(sum[:])+".so")
+}
+
+func (suite *FSSuite) TestDefaultCacheDir() {
+	suite.Equal("pluginfx", filepath.Base(DefaultCacheDir()))
+}
+
+func (suite *FSSuite) TestMaterialize() {
+	var (
+		dir  = filepath.Join(suite.T().TempDir(), "cache")
+		fsys = fstest.MapFS{
+			"plugins/a.so": &fstest.MapFile{Data: []byte("a")},
+			"plugins/b.so": &fstest.MapFile{Data: []byte("b")},
+		}
+
+		sum      = sha256.Sum256([]byte("a"))
+		expected = filepath.Join(dir, hex.EncodeToString(sum[:])+".so")
+	)
+
+	path, err := materialize(fsys, "plugins/a.so", dir)
+	suite.Require().NoError(err)
+	suite.Equal(expected, path)
+
+	data, err := os.ReadFile(path)
+	suite.NoError(err)
+	suite.Equal([]byte("a"), data)
+
+	fi, err := os.Stat(dir)
+	suite.Require().NoError(err)
+	suite.Equal(fs.FileMode(0700), fi.Mode().Perm())
+
+	suite.Run("Reuse", func() {
+		again, err := materialize(fsys, "plugins/a.so", dir)
+		suite.NoError(err)
+		suite.Equal(path, again)
+
+		entries, err := os.ReadDir(dir)
+		suite.NoError(err)
+		suite.Len(entries, 1)
+	})
+
+	suite.Run("DifferentContent", func() {
+		other, err := materialize(fsys, "plugins/b.so", dir)
+		suite.NoError(err)
+		suite.NotEqual(path, other)
+	})
+
+	suite.Run("Missing", func() {
+		_, err := materialize(fsys, "plugins/nosuch.so", dir)
+		suite.ErrorIs(err, fs.ErrNotExist)
+	})
+}
+
+func (suite *FSSuite) TestP() {
+	suite.Run("Cleanup", func() {
+		var (
+			name     string
+			registry *Registry
+
+			fsys, cached = suite.embeddedFS("plugins/embedded.so")
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous:     true,
+					Path:          "plugins/embedded.so",
+					FS:            fsys,
+					CacheDir:      testCacheDir,
+					CleanupOnStop: true,
+					Symbols: Symbols{
+						Names: []interface{}{"Name"},
+					},
+				}.Provide(),
+				ProvideRegistry(),
+				fx.Populate(&name, &registry),
+			)
+		)
+
+		app.RequireStart()
+		suite.Equal("embedded", name)
+		suite.FileExists(cached)
+
+		record, ok := registry.Get("plugins/embedded.so")
+		suite.Require().True(ok)
+		suite.Equal(cached, record.ExpandedPath)
+
+		app.RequireStop()
+		suite.NoFileExists(cached)
+	})
+
+	suite.Run("Reuse", func() {
+		fsys, cached := suite.embeddedFS("embedded.so")
+		for i := 0; i < 2; i++ {
+			var name string
+			app := fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      "embedded.so",
+					FS:        fsys,
+					CacheDir:  testCacheDir,
+					Symbols: Symbols{
+						Names: []interface{}{"Name"},
+					},
+				}.Provide(),
+				fx.Populate(&name),
+			)
+
+			app.RequireStart()
+			app.RequireStop()
+			suite.Equal("embedded", name)
+			suite.FileExists(cached)
+		}
+	})
+
+	suite.Run("Fallback", func() {
+		var (
+			recorder eventRecorder
+			name     string
+
+			fsys, _ = suite.embeddedFS("embedded.so")
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      "embedded-{goos}.so",
+					Template:  true,
+					Fallbacks: []string{"embedded.so"},
+					FS:        fsys,
+					CacheDir:  testCacheDir,
+					Logger:    &recorder,
+					Symbols: Symbols{
+						Names: []interface{}{"Name"},
+					},
+				}.Provide(),
+				fx.Populate(&name),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal("embedded", name)
+
+		suite.Require().NotEmpty(recorder.events)
+		selected, ok := recorder.events[0].(*Selected)
+		suite.Require().True(ok)
+		suite.Equal("embedded.so", selected.Path)
+	})
+
+	suite.Run("Missing", func() {
+		fsys, _ := suite.embeddedFS("embedded.so")
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      "nosuch.so",
+				FS:        fsys,
+				CacheDir:  testCacheDir,
+			}.Provide(),
+		)
+
+		err := app.Err()
+		suite.ErrorIs(err, fs.ErrNotExist)
+
+		var pe *PluginError
+		suite.Require().True(errors.As(err, &pe))
+		suite.Equal(PhaseOpen, pe.Phase)
+		suite.Equal("nosuch.so", pe.Path)
+	})
+}
+
+func (suite *FSSuite) TestS() {
+	var (
+		plugins []Plugin
+
+		fsys, cached = suite.embeddedFS("plugins/embedded.so")
+
+		app = fxtest.New(
+			suite.T(),
+			S{
+				Group:         "plugins",
+				Paths:         []string{"plugins/*.so", "nosuch/*.so"},
+				FS:            fsys,
+				CacheDir:      testCacheDir,
+				CleanupOnStop: true,
+			}.Provide(),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					Plugins []Plugin `group:"plugins"`
+				}) {
+					plugins = in.Plugins
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	suite.Len(plugins, 1)
+	suite.FileExists(cached)
+
+	app.RequireStop()
+	suite.NoFileExists(cached)
+}
+
+func TestFS(t *testing.T) {
+	suite.Run(t, new(FSSuite))
+}
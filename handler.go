@@ -0,0 +1,167 @@
+package pluginfx
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// SymbolView is the rendered form of a BoundSymbol.
+type SymbolView struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Type string `json:"type"`
+}
+
+// PluginView is the rendered form of a Record, as written by Handler.
+type PluginView struct {
+	Path         string       `json:"path"`
+	ExpandedPath string       `json:"expandedPath"`
+	Name         string       `json:"name,omitempty"`
+	Group        string       `json:"group,omitempty"`
+	Status       string       `json:"status"`
+	Condition    string       `json:"condition,omitempty"`
+	Original     string       `json:"original,omitempty"`
+	Symbols      []SymbolView `json:"symbols"`
+	OnStart      string       `json:"onStart,omitempty"`
+	OnStop       string       `json:"onStop,omitempty"`
+	Errors       []string     `json:"errors,omitempty"`
+	LoadTime     time.Time    `json:"loadTime"`
+
+	// Module, Version, and GoVersion describe how the running plugin was built, as read
+	// when it was opened.  They are omitted if the plugin was not opened or has no build
+	// information.
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+}
+
+// NewPluginView produces the rendered form of a Record.
+func NewPluginView(r Record) PluginView {
+	pv := PluginView{
+		Path:         r.Path,
+		ExpandedPath: r.ExpandedPath,
+		Name:         r.Name,
+		Group:        r.Group,
+		Status:       r.Status.String(),
+		Condition:    r.Condition,
+		Original:     r.Original,
+		Symbols:      make([]SymbolView, 0, len(r.Symbols)),
+		OnStart:      r.OnStart,
+		OnStop:       r.OnStop,
+		LoadTime:     r.LoadTime,
+	}
+
+	for _, s := range r.Symbols {
+		sv := SymbolView{
+			Name: s.Name,
+			Kind: s.Kind.String(),
+		}
+
+		if s.Type != nil {
+			sv.Type = s.Type.String()
+		}
+
+		pv.Symbols = append(pv.Symbols, sv)
+	}
+
+	for _, err := range multierr.Errors(r.Err) {
+		pv.Errors = append(pv.Errors, err.Error())
+	}
+
+	if b := r.Build; b != nil {
+		pv.Module = b.Module
+		pv.Version = b.Version
+		pv.GoVersion = b.GoVersion
+	}
+
+	return pv
+}
+
+var handlerTemplate = template.Must(template.New("plugins").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Plugins</title></head>
+<body>
+<table border="1">
+<tr><th>Path</th><th>Name</th><th>Group</th><th>Status</th><th>Version</th><th>Symbols</th><th>Lifecycle</th><th>Errors</th><th>Loaded</th></tr>
+{{range .}}<tr>
+<td>{{.ExpandedPath}}{{if ne .Path .ExpandedPath}}<br>({{.Path}}){{end}}</td>
+<td>{{.Name}}</td>
+<td>{{.Group}}</td>
+<td>{{.Status}}{{with .Condition}}<br>({{.}}){{end}}{{with .Original}}<br>(of {{.}}){{end}}</td>
+<td>{{with .Module}}{{.}} {{end}}{{.Version}}{{with .GoVersion}}<br>{{.}}{{end}}</td>
+<td>{{range .Symbols}}{{.Kind}} {{.Name}} {{.Type}}<br>{{end}}</td>
+<td>{{with .OnStart}}OnStart: {{.}}<br>{{end}}{{with .OnStop}}OnStop: {{.}}{{end}}</td>
+<td>{{range .Errors}}{{.}}<br>{{end}}</td>
+<td>{{.LoadTime.Format "2006-01-02T15:04:05Z07:00"}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Handler is an http.Handler that renders the plugins in a Registry.  This handler
+// is intended to be mounted in a host application's mux:
+//
+//   fx.Invoke(
+//     func(mux *http.ServeMux, r *pluginfx.Registry) {
+//       mux.Handle("/plugins", pluginfx.Handler{Registry: r})
+//     },
+//   )
+//
+// By default, plugins are rendered as a JSON array of PluginView objects.  A simple
+// HTML view is rendered instead if the request has a format=html query parameter or
+// its Accept header prefers text/html.
+type Handler struct {
+	// Registry is the source of plugin information.  If unset, an empty
+	// list of plugins is rendered.
+	Registry *Registry
+}
+
+var _ http.Handler = Handler{}
+
+func (h Handler) views() []PluginView {
+	var records []Record
+	if h.Registry != nil {
+		records = h.Registry.Records()
+	}
+
+	views := make([]PluginView, 0, len(records))
+	for _, r := range records {
+		views = append(views, NewPluginView(r))
+	}
+
+	return views
+}
+
+func wantsHTML(request *http.Request) bool {
+	switch request.URL.Query().Get("format") {
+	case "html":
+		return true
+
+	case "json":
+		return false
+
+	default:
+		return strings.HasPrefix(request.Header.Get("Accept"), "text/html")
+	}
+}
+
+// ServeHTTP renders the plugins in this handler's Registry.
+func (h Handler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	views := h.views()
+	if wantsHTML(request) {
+		response.Header().Set("Content-Type", "text/html; charset=utf-8")
+		handlerTemplate.Execute(response, views)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(response)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(views)
+}
@@ -0,0 +1,161 @@
+package pluginfx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
+)
+
+type HandlerSuite struct {
+	suite.Suite
+}
+
+func (suite *HandlerSuite) newRegistry() *Registry {
+	var registry *Registry
+	app := fxtest.New(
+		suite.T(),
+		P{
+			Name: "sample",
+			Path: samplePath,
+			Symbols: Symbols{
+				Names: []interface{}{"New"},
+			},
+			Lifecycle: Lifecycle{
+				OnStart: "Initialize",
+			},
+		}.Provide(),
+		ProvideRegistry(),
+		fx.Populate(&registry),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	return registry
+}
+
+func (suite *HandlerSuite) serve(h Handler, target, accept string) *httptest.ResponseRecorder {
+	var (
+		response = httptest.NewRecorder()
+		request  = httptest.NewRequest("GET", target, nil)
+	)
+
+	if len(accept) > 0 {
+		request.Header.Set("Accept", accept)
+	}
+
+	h.ServeHTTP(response, request)
+	suite.Equal(http.StatusOK, response.Code)
+	return response
+}
+
+func (suite *HandlerSuite) TestNewPluginView() {
+	suite.Run("Missing", func() {
+		pv := NewPluginView(Record{
+			Path:         "${PWD}/nosuch.so",
+			ExpandedPath: "/nosuch.so",
+			Status:       StatusFailed,
+			Symbols: []BoundSymbol{
+				{Name: "New", Kind: ProvideSymbol, Type: reflect.TypeOf(func() int { return 0 })},
+				{Name: "Untyped", Kind: InvokeSymbol},
+			},
+			Err: multierr.Combine(
+				errors.New("first"),
+				errors.New("second"),
+			),
+		})
+
+		suite.Equal("failed", pv.Status)
+		suite.Equal(
+			[]SymbolView{
+				{Name: "New", Kind: "PROVIDE", Type: "func() int"},
+				{Name: "Untyped", Kind: "INVOKE"},
+			},
+			pv.Symbols,
+		)
+
+		suite.Equal([]string{"first", "second"}, pv.Errors)
+		suite.Empty(pv.Module)
+		suite.Empty(pv.Version)
+		suite.Empty(pv.GoVersion)
+	})
+
+	suite.Run("NotOpened", func() {
+		// build information comes from the Record, not from the file on disk
+		pv := NewPluginView(Record{ExpandedPath: samplePath})
+		suite.Empty(pv.Module)
+		suite.NotNil(pv.Symbols)
+	})
+
+	suite.Run("Build", func() {
+		pv := NewPluginView(Record{
+			ExpandedPath: samplePath,
+			Build: &BuildInfo{
+				Path:      "example.com/plugins/auth",
+				Module:    "example.com/plugins",
+				Version:   "v1.2.3",
+				GoVersion: "go1.18",
+			},
+		})
+
+		suite.Equal("example.com/plugins", pv.Module)
+		suite.Equal("v1.2.3", pv.Version)
+		suite.Equal("go1.18", pv.GoVersion)
+	})
+}
+
+func (suite *HandlerSuite) TestJSON() {
+	var (
+		h        = Handler{Registry: suite.newRegistry()}
+		response = suite.serve(h, "/plugins", "")
+		views    []PluginView
+	)
+
+	suite.Equal("application/json", response.Header().Get("Content-Type"))
+	suite.Require().NoError(json.Unmarshal(response.Body.Bytes(), &views))
+	suite.Require().Len(views, 1)
+	suite.Equal(samplePath, views[0].Path)
+	suite.Equal("sample", views[0].Name)
+	suite.Equal("started", views[0].Status)
+	suite.Equal("Initialize", views[0].OnStart)
+	suite.Require().Len(views[0].Symbols, 1)
+	suite.Equal("New", views[0].Symbols[0].Name)
+	suite.WithinDuration(time.Now(), views[0].LoadTime, time.Minute)
+	suite.Equal("github.com/xmidt-org/pluginfx", views[0].Module)
+	suite.NotEmpty(views[0].Version)
+	suite.Equal(runtime.Version(), views[0].GoVersion)
+
+	response = suite.serve(h, "/plugins?format=json", "text/html")
+	suite.Equal("application/json", response.Header().Get("Content-Type"))
+}
+
+func (suite *HandlerSuite) TestHTML() {
+	h := Handler{Registry: suite.newRegistry()}
+	for _, response := range []*httptest.ResponseRecorder{
+		suite.serve(h, "/plugins?format=html", ""),
+		suite.serve(h, "/plugins", "text/html,application/xhtml+xml"),
+	} {
+		suite.Equal("text/html; charset=utf-8", response.Header().Get("Content-Type"))
+		suite.Contains(response.Body.String(), samplePath)
+		suite.Contains(response.Body.String(), "OnStart: Initialize")
+		suite.Contains(response.Body.String(), runtime.Version())
+	}
+}
+
+func (suite *HandlerSuite) TestNoRegistry() {
+	response := suite.serve(Handler{}, "/plugins", "")
+	suite.JSONEq("[]", response.Body.String())
+}
+
+func TestHandler(t *testing.T) {
+	suite.Run(t, new(HandlerSuite))
+}
@@ -0,0 +1,68 @@
+package pluginfx
+
+import (
+	"reflect"
+)
+
+// SymbolInfo describes how a single plugin symbol would be treated by Symbols
+// and Lifecycle, without binding it to an fx.App.
+type SymbolInfo struct {
+	// Name is the symbol name.
+	Name string
+
+	// Err is the error returned by Lookup.  If this field is set, the
+	// symbol was not found and all other fields are unset.
+	Err error
+
+	// Type is the Go type of the symbol.  For variables, this will be a pointer type.
+	Type reflect.Type
+
+	// Function indicates whether the symbol is a function.  If this field is false,
+	// the symbol cannot be used with Symbols or Lifecycle.
+	Function bool
+
+	// Kind is how the symbol would be bound when named by a string in Symbols.Names,
+	// either ProvideSymbol or InvokeSymbol.  This field is only meaningful
+	// when Function is true.
+	Kind SymbolKind
+
+	// Target indicates whether the symbol is legal for Annotated.Target.
+	Target bool
+
+	// Lifecycle indicates whether the symbol is legal for Lifecycle.OnStart
+	// or Lifecycle.OnStop.
+	Lifecycle bool
+}
+
+// Found tests if the symbol exists in the plugin.
+func (si SymbolInfo) Found() bool {
+	return si.Err == nil
+}
+
+// Inspect looks up each of the given symbol names and describes how they would be
+// treated by Symbols.Load and Lifecycle.Bind.  This function never binds anything
+// to an fx.App.
+func Inspect(p Plugin, names ...string) []SymbolInfo {
+	infos := make([]SymbolInfo, 0, len(names))
+	for _, name := range names {
+		info := SymbolInfo{
+			Name: name,
+		}
+
+		var symbol interface{}
+		symbol, info.Err = Lookup(p, name)
+		if info.Err == nil {
+			info.Type = reflect.TypeOf(symbol)
+			info.Function = info.Type.Kind() == reflect.Func
+			if info.Function {
+				info.Kind = constructorOrInvokeKind(info.Type)
+				info.Target = isValidTarget(info.Type)
+				info.Lifecycle = asLifecycleCallback(symbol) != nil
+			}
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
@@ -0,0 +1,66 @@
+package pluginfx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type InspectSuite struct {
+	PluginfxSuite
+}
+
+func (suite *InspectSuite) TestSymbolMap() {
+	infos := Inspect(
+		NewSymbols(
+			"Constructor", func() (int, error) { return 0, nil },
+			"Invoke", func(int) {},
+			"Lifecycle", func(context.Context) error { return nil },
+			"Value", 123,
+		),
+		"Constructor", "Invoke", "Lifecycle", "Value", "Missing",
+	)
+
+	suite.Require().Len(infos, 5)
+
+	suite.Equal("Constructor", infos[0].Name)
+	suite.True(infos[0].Found())
+	suite.True(infos[0].Function)
+	suite.Equal(ProvideSymbol, infos[0].Kind)
+	suite.True(infos[0].Target)
+	suite.False(infos[0].Lifecycle)
+
+	suite.Equal(InvokeSymbol, infos[1].Kind)
+	suite.False(infos[1].Target)
+	suite.False(infos[1].Lifecycle)
+
+	suite.Equal(InvokeSymbol, infos[2].Kind)
+	suite.False(infos[2].Target)
+	suite.True(infos[2].Lifecycle)
+
+	suite.True(infos[3].Found())
+	suite.False(infos[3].Function)
+	suite.Equal(reflect.TypeOf((*int)(nil)), infos[3].Type)
+
+	suite.False(infos[4].Found())
+	suite.missingSymbolError("Missing", infos[4].Err)
+	suite.Nil(infos[4].Type)
+}
+
+func (suite *InspectSuite) TestSample() {
+	p := suite.openSuccess(Open(samplePath))
+	infos := Inspect(p, "New", "Initialize")
+	suite.Require().Len(infos, 2)
+
+	suite.Equal(ProvideSymbol, infos[0].Kind)
+	suite.True(infos[0].Target)
+
+	suite.Equal(InvokeSymbol, infos[1].Kind)
+	suite.True(infos[1].Lifecycle)
+}
+
+func TestInspect(t *testing.T) {
+	suite.Run(t, new(InspectSuite))
+}
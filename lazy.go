@@ -0,0 +1,212 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"plugin"
+	"reflect"
+	"sync"
+
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+)
+
+// ErrLazyContract indicates that a symbol of a lazy plugin has no suitable contract.  Every
+// symbol bound from a lazy plugin must have a function contract whose last result is an error,
+// so that its constructor can be registered before the plugin is opened and can report a
+// failure to open.
+var ErrLazyContract = errors.New("A lazy plugin symbol requires a function contract that returns an error")
+
+// lazyPlugin is a Plugin that defers opening the real plugin until one of its functions
+// is first called.  Lookup returns a stub of the contract type for each symbol, so that
+// the stub can be bound to an fx.App before anything is opened.
+type lazyPlugin struct {
+	contracts map[string]interface{}
+	open      func() (Plugin, error)
+
+	once   sync.Once
+	plugin Plugin
+	err    error
+}
+
+// get opens the real plugin, if that hasn't happened yet.  The plugin is opened at most once,
+// and any error is returned from each call.
+func (lp *lazyPlugin) get() (Plugin, error) {
+	lp.once.Do(func() {
+		lp.plugin, lp.err = lp.open()
+	})
+
+	return lp.plugin, lp.err
+}
+
+// contract returns the function type that a lazy symbol is stubbed with.
+func (lp *lazyPlugin) contract(name string) (reflect.Type, error) {
+	contract, ok := lp.contracts[name]
+	if !ok {
+		return nil, fmt.Errorf("No contract for symbol %s: %w", name, ErrLazyContract)
+	}
+
+	ft, err := contractType(contract)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid contract %T for symbol %s: %w", contract, name, err)
+	}
+
+	if ft.Kind() != reflect.Func || ft.NumOut() == 0 || ft.Out(ft.NumOut()-1) != errType {
+		return nil, fmt.Errorf("Contract %s for symbol %s: %w", ft, name, ErrLazyContract)
+	}
+
+	return ft, nil
+}
+
+// Lookup returns a stub function with the type of the named symbol's contract.
+func (lp *lazyPlugin) Lookup(name string) (plugin.Symbol, error) {
+	ft, err := lp.contract(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return reflect.MakeFunc(ft, lp.stub(name, ft)).Interface(), nil
+}
+
+// lazySymbols returns the name of every symbol that a lazy P binds or verifies.
+func (p P) lazySymbols() (names []string) {
+	for _, n := range p.Symbols.Names {
+		switch name := n.(type) {
+		case string:
+			names = append(names, name)
+
+		case Annotated:
+			names = append(names, name.Target)
+		}
+	}
+
+	for _, name := range []string{p.Lifecycle.OnStart, p.Lifecycle.OnStop} {
+		if len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+
+	return append(names, p.Symbols.contractOnly()...)
+}
+
+// checkContracts verifies that every symbol of a lazy P has a suitable contract.  This
+// happens before the plan is built, because a stub that cannot be created would otherwise
+// be reported as a missing symbol, which IgnoreMissing silently drops.
+func (p P) checkContracts(l loader, lp *lazyPlugin) error {
+	var errs []error
+	for _, name := range p.lazySymbols() {
+		if _, err := lp.contract(name); err != nil {
+			l.log(&SymbolRejected{Path: l.path, Name: name, Err: err})
+			errs = append(errs, &PluginError{
+				Path:   l.path,
+				Symbol: name,
+				Phase:  PhaseProvide,
+				Err:    err,
+			})
+		}
+	}
+
+	return multierr.Combine(errs...)
+}
+
+// stub returns the implementation of a lazy symbol.  The first call opens the plugin, and
+// each call looks up the real symbol and calls it.  If the plugin cannot be opened or the
+// real symbol doesn't match its contract, the error is returned as the last result.
+func (lp *lazyPlugin) stub(name string, ft reflect.Type) func([]reflect.Value) []reflect.Value {
+	return func(args []reflect.Value) []reflect.Value {
+		target, err := lp.target(name, ft)
+		if err != nil {
+			results := make([]reflect.Value, ft.NumOut())
+			for i := range results {
+				results[i] = reflect.Zero(ft.Out(i))
+			}
+
+			results[len(results)-1] = reflect.ValueOf(&err).Elem()
+			return results
+		}
+
+		if ft.IsVariadic() {
+			return target.CallSlice(args)
+		}
+
+		return target.Call(args)
+	}
+}
+
+// target opens the plugin and returns the real function for a symbol.
+func (lp *lazyPlugin) target(name string, ft reflect.Type) (reflect.Value, error) {
+	p, err := lp.get()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	symbol, err := Lookup(p, name)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	if actual := reflect.TypeOf(symbol); actual != ft {
+		return reflect.Value{}, &ContractError{
+			Name:     name,
+			Expected: ft,
+			Actual:   actual,
+		}
+	}
+
+	return reflect.ValueOf(symbol), nil
+}
+
+// provideLazy binds this plugin's symbols, via stubs, without opening it.  The plugin is
+// opened and configured when the first stub is called or, if this P is not anonymous,
+// when the plugin component itself is first needed.
+func (p P) provideLazy(l loader) fx.Option {
+	l.entry.record.Status = StatusPending
+
+	var (
+		path = l.path
+		lp   = &lazyPlugin{
+			contracts: p.Symbols.Contracts,
+			open: func() (Plugin, error) {
+				plugin, err := l.open()
+				if err != nil {
+					return nil, &PluginError{
+						Path:  path,
+						Phase: PhaseOpen,
+						Err:   err,
+					}
+				}
+
+				if err := p.configure(l, plugin); err != nil {
+					return nil, err
+				}
+
+				return plugin, nil
+			},
+		}
+	)
+
+	if err := p.checkContracts(l, lp); err != nil {
+		return p.fail(l, err)
+	}
+
+	options := []fx.Option{
+		l.provideEntry(),
+		NewPlan(lp, p.Symbols, p.Lifecycle).options(l),
+	}
+
+	switch {
+	case !p.Anonymous && (len(p.Name) > 0 || len(p.Group) > 0):
+		options = append(options, fx.Provide(
+			fx.Annotated{
+				Name:   p.Name,
+				Group:  p.Group,
+				Target: lp.get,
+			},
+		))
+
+	case !p.Anonymous:
+		options = append(options, fx.Provide(lp.get))
+	}
+
+	return fx.Options(options...)
+}
@@ -0,0 +1,289 @@
+package pluginfx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type LazySuite struct {
+	PluginfxSuite
+}
+
+// newLazyPlugin creates a lazyPlugin that counts how many times it is opened.
+func (suite *LazySuite) newLazyPlugin(contracts map[string]interface{}, p Plugin, err error) (*lazyPlugin, *int) {
+	opened := new(int)
+	return &lazyPlugin{
+		contracts: contracts,
+		open: func() (Plugin, error) {
+			*opened++
+			return p, err
+		},
+	}, opened
+}
+
+func (suite *LazySuite) TestLookup() {
+	lp, opened := suite.newLazyPlugin(
+		map[string]interface{}{
+			"New":     (func(int) (string, error))(nil),
+			"Reader":  (*context.Context)(nil),
+			"NoError": (func() string)(nil),
+			"Invalid": 123,
+		},
+		nil, nil,
+	)
+
+	suite.Run("Stub", func() {
+		symbol, err := lp.Lookup("New")
+		suite.Require().NoError(err)
+		suite.IsType((func(int) (string, error))(nil), symbol)
+	})
+
+	for _, name := range []string{"Missing", "Reader", "NoError"} {
+		suite.Run(name, func() {
+			symbol, err := lp.Lookup(name)
+			suite.Nil(symbol)
+			suite.ErrorIs(err, ErrLazyContract)
+			suite.Contains(err.Error(), name)
+		})
+	}
+
+	suite.Run("Invalid", func() {
+		_, err := lp.Lookup("Invalid")
+		suite.ErrorIs(err, ErrInvalidContract)
+	})
+
+	suite.Zero(*opened)
+}
+
+func (suite *LazySuite) TestStub() {
+	contracts := map[string]interface{}{
+		"New":      (func(int) (string, error))(nil),
+		"Join":     (func(string, ...string) (string, error))(nil),
+		"Mismatch": (func() (int, error))(nil),
+		"Missing":  (func() error)(nil),
+	}
+
+	suite.Run("Success", func() {
+		lp, opened := suite.newLazyPlugin(
+			contracts,
+			NewSymbols(
+				"New", func(v int) (string, error) { return strings.Repeat("x", v), nil },
+				"Join", func(sep string, v ...string) (string, error) { return strings.Join(v, sep), nil },
+			),
+			nil,
+		)
+
+		symbol, err := lp.Lookup("New")
+		suite.Require().NoError(err)
+		suite.Zero(*opened)
+
+		s, err := symbol.(func(int) (string, error))(3)
+		suite.NoError(err)
+		suite.Equal("xxx", s)
+		suite.Equal(1, *opened)
+
+		symbol, err = lp.Lookup("Join")
+		suite.Require().NoError(err)
+
+		s, err = symbol.(func(string, ...string) (string, error))("-", "a", "b")
+		suite.NoError(err)
+		suite.Equal("a-b", s)
+		suite.Equal(1, *opened)
+	})
+
+	suite.Run("OpenError", func() {
+		expectedErr := errors.New("expected")
+		lp, opened := suite.newLazyPlugin(contracts, nil, expectedErr)
+
+		symbol, err := lp.Lookup("New")
+		suite.Require().NoError(err)
+
+		for i := 0; i < 2; i++ {
+			s, err := symbol.(func(int) (string, error))(3)
+			suite.Same(expectedErr, err)
+			suite.Empty(s)
+		}
+
+		suite.Equal(1, *opened)
+	})
+
+	suite.Run("ContractMismatch", func() {
+		lp, _ := suite.newLazyPlugin(contracts, NewSymbols("Mismatch", func() (string, error) { return "", nil }), nil)
+
+		symbol, err := lp.Lookup("Mismatch")
+		suite.Require().NoError(err)
+
+		_, err = symbol.(func() (int, error))()
+		var ce *ContractError
+		suite.Require().True(errors.As(err, &ce))
+		suite.Equal("Mismatch", ce.Name)
+	})
+
+	suite.Run("MissingSymbol", func() {
+		lp, _ := suite.newLazyPlugin(contracts, NewSymbols(), nil)
+
+		symbol, err := lp.Lookup("Missing")
+		suite.Require().NoError(err)
+		suite.missingSymbolError("Missing", symbol.(func() error)())
+	})
+}
+
+func (suite *LazySuite) lazyP(path string, logger Logger) P {
+	return P{
+		Anonymous: true,
+		Path:      path,
+		Lazy:      true,
+		Logger:    logger,
+		Symbols: Symbols{
+			Names: []interface{}{"New"},
+			Contracts: map[string]interface{}{
+				"New": (func() (float64, error))(nil),
+			},
+		},
+	}
+}
+
+func (suite *LazySuite) TestP() {
+	suite.Run("Unused", func() {
+		var (
+			recorder eventRecorder
+			registry *Registry
+			app      = fxtest.New(
+				suite.T(),
+				suite.lazyP(samplePath, &recorder).Provide(),
+				ProvideRegistry(),
+				fx.Populate(&registry),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		for _, e := range recorder.events {
+			_, opened := e.(*Opened)
+			suite.False(opened)
+		}
+
+		record, ok := registry.Get(samplePath)
+		suite.Require().True(ok)
+		suite.Equal(StatusPending, record.Status)
+		suite.Require().Len(record.Symbols, 1)
+		suite.Equal("New", record.Symbols[0].Name)
+	})
+
+	suite.Run("Used", func() {
+		var (
+			recorder eventRecorder
+			registry *Registry
+			value    float64
+			app      = fxtest.New(
+				suite.T(),
+				suite.lazyP(samplePath, &recorder).Provide(),
+				ProvideRegistry(),
+				fx.Populate(&registry, &value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(67.5, value)
+		suite.Contains(recorder.events, &Opened{Path: sampleOpenPath})
+
+		record, ok := registry.Get(samplePath)
+		suite.Require().True(ok)
+		suite.Equal(StatusLoaded, record.Status)
+	})
+
+	suite.Run("PluginComponent", func() {
+		var (
+			p   Plugin
+			lp  = suite.lazyP(samplePath, nil)
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Name:    "sample",
+					Path:    lp.Path,
+					Lazy:    true,
+					Symbols: lp.Symbols,
+				}.Provide(),
+				fx.Invoke(
+					func(in struct {
+						fx.In
+						Plugin Plugin `name:"sample"`
+					}) {
+						p = in.Plugin
+					},
+				),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Require().NotNil(p)
+	})
+
+	suite.Run("OpenError", func() {
+		path := filepath.Join(suite.T().TempDir(), "text.so")
+		suite.Require().NoError(os.WriteFile(path, []byte("not a plugin"), 0600))
+
+		unused := fxtest.New(suite.T(), suite.lazyP(path, nil).Provide())
+		unused.RequireStart()
+		unused.RequireStop()
+
+		var value float64
+		app := fx.New(
+			suite.lazyP(path, nil).Provide(),
+			fx.Populate(&value),
+		)
+
+		// dig reports constructor errors by message only
+		suite.Require().Error(app.Err())
+		suite.Contains(app.Err().Error(), path)
+		suite.Contains(app.Err().Error(), string(PhaseOpen))
+	})
+
+	suite.Run("NoContract", func() {
+		lp := suite.lazyP(samplePath, nil)
+		lp.Symbols.Contracts = nil
+
+		app := fx.New(lp.Provide())
+		suite.ErrorIs(app.Err(), ErrLazyContract)
+		suite.NotContains(app.Err().Error(), string(PhaseLookup))
+	})
+
+	suite.Run("NoContractIgnoreMissing", func() {
+		// a missing contract is not a missing symbol, so IgnoreMissing must not hide it
+		var (
+			recorder eventRecorder
+			lp       = suite.lazyP(samplePath, &recorder)
+		)
+
+		lp.Symbols.Contracts = nil
+		lp.Symbols.IgnoreMissing = true
+
+		app := fx.New(lp.Provide())
+		suite.Require().ErrorIs(app.Err(), ErrLazyContract)
+
+		var pe *PluginError
+		suite.Require().True(errors.As(app.Err(), &pe))
+		suite.Equal("New", pe.Symbol)
+		suite.Equal(PhaseProvide, pe.Phase)
+
+		suite.Require().Len(recorder.events, 1)
+		rejected, ok := recorder.events[0].(*SymbolRejected)
+		suite.Require().True(ok)
+		suite.Equal("New", rejected.Name)
+	})
+}
+
+func TestLazy(t *testing.T) {
+	suite.Run(t, new(LazySuite))
+}
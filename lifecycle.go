@@ -20,33 +20,25 @@ func (ile *InvalidLifecycleError) Error() string {
 	return fmt.Sprintf("Symbol %s of type %T is not a valid lifecycle callback", ile.Name, ile.Type)
 }
 
-func lookupLifecycle(s Plugin, name string) (callback func(context.Context) error, err error) {
-	var symbol plugin.Symbol
-	symbol, err = Lookup(s, name)
-
-	if err == nil {
-		switch f := symbol.(type) {
-		case func():
-			callback = func(context.Context) error { f(); return nil }
+// asLifecycleCallback normalizes a symbol into an fx.Hook callback.  If the symbol
+// does not have one of the supported signatures, this function returns nil.
+func asLifecycleCallback(symbol plugin.Symbol) func(context.Context) error {
+	switch f := symbol.(type) {
+	case func():
+		return func(context.Context) error { f(); return nil }
 
-		case func() error:
-			callback = func(context.Context) error { return f() }
+	case func() error:
+		return func(context.Context) error { return f() }
 
-		case func(context.Context):
-			callback = func(ctx context.Context) error { f(ctx); return nil }
+	case func(context.Context):
+		return func(ctx context.Context) error { f(ctx); return nil }
 
-		case func(context.Context) error:
-			callback = f
+	case func(context.Context) error:
+		return f
 
-		default:
-			err = &InvalidLifecycleError{
-				Name: name,
-				Type: reflect.TypeOf(symbol),
-			}
-		}
+	default:
+		return nil
 	}
-
-	return
 }
 
 // Lifecycle describes how to bind a plugin to an enclosing application's lifecycle.
@@ -74,41 +66,59 @@ type Lifecycle struct {
 	// If this field is true, a missing OnStart or OnStop is silently ignored.  If this field is false,
 	// then a missing OnStart or OnStop from a plugin will shortcircuit application startup with an error.
 	IgnoreMissing bool
+
+	// Logger is the optional sink for the events that occur while Bind binds the callbacks,
+	// each with an empty Path.  This field is ignored when this Lifecycle is part of a P or S,
+	// which report to their own Logger.
+	Logger Logger
+
+	// Metrics is the optional sink for measurements of the callbacks bound by Bind, with an empty
+	// Labels.Path.  This field is ignored when this Lifecycle is part of a P or S, which report
+	// to their own Metrics.
+	Metrics Metrics
 }
 
-// Bind binds the given plugin to the enclosing application's lifecycle, using
-// the symbol information configured in OnStart and OnStop.
-func (lc Lifecycle) Bind(p Plugin) fx.Option {
-	var (
-		hook    fx.Hook
-		options []fx.Option
-	)
+// step resolves a single lifecycle callback against a plugin.
+func (lc Lifecycle) step(p Plugin, name string, kind SymbolKind) (st Step) {
+	st.Symbol = name
+	st.Kind = kind
 
-	if len(lc.OnStart) > 0 {
-		var err error
-		hook.OnStart, err = lookupLifecycle(p, lc.OnStart)
-		missing := IsMissingSymbolError(err)
-		if (missing && !lc.IgnoreMissing) || (!missing && err != nil) {
-			options = append(options, fx.Error(err))
+	var symbol plugin.Symbol
+	symbol, st.Err = Lookup(p, name)
+	if st.Err == nil {
+		st.setType(reflect.TypeOf(symbol))
+		st.callback = asLifecycleCallback(symbol)
+		if st.callback == nil {
+			st.Err = &InvalidLifecycleError{
+				Name: name,
+				Type: st.Type,
+			}
 		}
 	}
 
-	if len(lc.OnStop) > 0 {
-		var err error
-		hook.OnStop, err = lookupLifecycle(p, lc.OnStop)
-		missing := IsMissingSymbolError(err)
-		if (missing && !lc.IgnoreMissing) || (!missing && err != nil) {
-			options = append(options, fx.Error(err))
-		}
+	st.Ignored = lc.IgnoreMissing && IsMissingSymbolError(st.Err)
+	return
+}
+
+// Plan resolves the configured OnStart and OnStop symbols against the given plugin,
+// without binding anything to an fx.App.
+func (lc Lifecycle) Plan(p Plugin) *Plan {
+	plan := new(Plan)
+	if len(lc.OnStart) > 0 {
+		plan.Steps = append(plan.Steps, lc.step(p, lc.OnStart, OnStartSymbol))
 	}
 
-	if len(options) == 0 && (hook.OnStart != nil || hook.OnStop != nil) {
-		return fx.Invoke(
-			func(l fx.Lifecycle) {
-				l.Append(hook)
-			},
-		)
+	if len(lc.OnStop) > 0 {
+		plan.Steps = append(plan.Steps, lc.step(p, lc.OnStop, OnStopSymbol))
 	}
 
-	return fx.Options(options...)
+	return plan
+}
+
+// Bind binds the given plugin to the enclosing application's lifecycle, using
+// the symbol information configured in OnStart and OnStop.  This method is
+// equivalent to lc.Plan(p).Options(), except that events are logged to Logger
+// and callbacks are measured by Metrics.
+func (lc Lifecycle) Bind(p Plugin) fx.Option {
+	return lc.Plan(p).options(loader{logger: lc.Logger, metrics: lc.Metrics})
 }
@@ -0,0 +1,310 @@
+package pluginfx
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/zap"
+)
+
+// ConsoleLogger is a Logger that writes human-readable messages to
+// an io.Writer, in the same format as fxevent.ConsoleLogger.
+type ConsoleLogger struct {
+	W io.Writer
+}
+
+var _ Logger = (*ConsoleLogger)(nil)
+
+func (l *ConsoleLogger) logf(msg string, args ...interface{}) {
+	fmt.Fprintf(l.W, "[pluginfx] "+msg+"\n", args...)
+}
+
+// LogEvent writes the given event to this logger's io.Writer.
+func (l *ConsoleLogger) LogEvent(event Event) {
+	switch e := event.(type) {
+	case *Opened:
+		l.logf("OPENED\t%s", e.Path)
+
+	case *OpenFailed:
+		l.logf("ERROR\tFailed to open %s: %+v", e.Path, e.Err)
+
+	case *SymbolBound:
+		l.logf("%s\t%s %v from %q", e.Kind, e.Name, e.Type, e.Path)
+
+	case *SymbolMissing:
+		if e.Ignored {
+			l.logf("SKIPPED\t%s is missing from %q", e.Name, e.Path)
+		} else {
+			l.logf("ERROR\t%s is missing from %q", e.Name, e.Path)
+		}
+
+	case *SymbolRejected:
+		l.logf("ERROR\t%s from %q rejected: %+v", e.Name, e.Path, e.Err)
+
+	case *LifecycleBound:
+		l.logf("LIFECYCLE\tOnStart=%q OnStop=%q from %q", e.OnStart, e.OnStop, e.Path)
+
+	case *Skipped:
+		l.logf("SKIPPED\tOptional plugin %s: %+v", e.Path, e.Err)
+
+	case *Configured:
+		l.logf("CONFIGURED\t%s from %q", e.Name, e.Path)
+
+	case *Disabled:
+		l.logf("DISABLED\t%s: %s", e.Path, e.Condition)
+
+	case *Selected:
+		l.logf("SELECTED\t%s from %q", e.Path, e.Candidates)
+
+	case *Fetched:
+		if e.Cached {
+			l.logf("FETCHED\t%s => %s (cached)", e.URL, e.Path)
+		} else {
+			l.logf("FETCHED\t%s => %s", e.URL, e.Path)
+		}
+
+	case *Built:
+		if e.Cached {
+			l.logf("BUILT\t%s => %s (cached)", e.Source, e.Path)
+		} else {
+			l.logf("BUILT\t%s => %s", e.Source, e.Path)
+		}
+
+	case *Duplicate:
+		if e.Alias {
+			l.logf("DUPLICATE\t%s is %s (alias)", e.Path, e.Original)
+		} else {
+			l.logf("DUPLICATE\t%s is %s (skipped)", e.Path, e.Original)
+		}
+	}
+}
+
+// ZapLogger is a Logger that logs events to zap.
+type ZapLogger struct {
+	Logger *zap.Logger
+}
+
+var _ Logger = (*ZapLogger)(nil)
+
+// LogEvent logs the given event to the provided zap logger.
+func (l *ZapLogger) LogEvent(event Event) {
+	switch e := event.(type) {
+	case *Opened:
+		l.Logger.Info("plugin opened",
+			zap.String("path", e.Path),
+		)
+
+	case *OpenFailed:
+		l.Logger.Error("plugin open failed",
+			zap.String("path", e.Path),
+			zap.Error(e.Err),
+		)
+
+	case *SymbolBound:
+		l.Logger.Info("symbol bound",
+			zap.String("path", e.Path),
+			zap.String("symbol", e.Name),
+			zap.Stringer("kind", e.Kind),
+			zap.Stringer("type", e.Type),
+		)
+
+	case *SymbolMissing:
+		if e.Ignored {
+			l.Logger.Info("symbol missing",
+				zap.String("path", e.Path),
+				zap.String("symbol", e.Name),
+				zap.Bool("ignored", true),
+			)
+		} else {
+			l.Logger.Error("symbol missing",
+				zap.String("path", e.Path),
+				zap.String("symbol", e.Name),
+				zap.Bool("ignored", false),
+			)
+		}
+
+	case *SymbolRejected:
+		l.Logger.Error("symbol rejected",
+			zap.String("path", e.Path),
+			zap.String("symbol", e.Name),
+			zap.Error(e.Err),
+		)
+
+	case *LifecycleBound:
+		l.Logger.Info("lifecycle bound",
+			zap.String("path", e.Path),
+			zap.String("onStart", e.OnStart),
+			zap.String("onStop", e.OnStop),
+		)
+
+	case *Skipped:
+		l.Logger.Warn("optional plugin skipped",
+			zap.String("path", e.Path),
+			zap.Error(e.Err),
+		)
+
+	case *Configured:
+		l.Logger.Info("plugin configured",
+			zap.String("path", e.Path),
+			zap.String("symbol", e.Name),
+		)
+
+	case *Disabled:
+		l.Logger.Info("plugin disabled",
+			zap.String("path", e.Path),
+			zap.String("condition", e.Condition),
+		)
+
+	case *Selected:
+		l.Logger.Info("plugin selected",
+			zap.String("path", e.Path),
+			zap.Strings("candidates", e.Candidates),
+		)
+
+	case *Fetched:
+		l.Logger.Info("plugin fetched",
+			zap.String("url", e.URL),
+			zap.String("path", e.Path),
+			zap.Bool("cached", e.Cached),
+		)
+
+	case *Built:
+		l.Logger.Info("plugin built",
+			zap.String("source", e.Source),
+			zap.String("path", e.Path),
+			zap.Bool("cached", e.Cached),
+		)
+
+	case *Duplicate:
+		l.Logger.Info("duplicate plugin",
+			zap.String("path", e.Path),
+			zap.String("original", e.Original),
+			zap.Bool("alias", e.Alias),
+		)
+	}
+}
+
+// FxeventLogger adapts an fxevent.Logger so that it can receive pluginfx events.
+// This lets plugin events show up in the same place as the enclosing fx.App's events.
+//
+// The fxevent.ConsoleLogger and fxevent.ZapLogger types are handled specially, with
+// each pluginfx event written to the same io.Writer or zap.Logger.  Any other fxevent.Logger
+// receives translated events, each with the plugin path as the module name:
+//
+//   - opened plugins are reported as an fxevent.Provided from pluginfx.Open, with an
+//     error if the plugin could not be opened
+//   - bound symbols are reported as fxevent.Provided or fxevent.Invoking
+//   - rejected symbols, and missing symbols that are not ignored, are reported as an
+//     fxevent.Provided with an error, which is a *MissingSymbolError for missing symbols
+//   - missing symbols that are ignored are reported as an fxevent.Provided, without an
+//     error, from pluginfx.IgnoreMissing with the symbol name, such as pluginfx.IgnoreMissing(New)
+//   - bound lifecycle callbacks are reported as an fxevent.Invoking of pluginfx.Lifecycle,
+//     since binding them invokes a function that appends an fx.Hook
+//
+// Other pluginfx events have no fxevent analog and are not reported.
+type FxeventLogger struct {
+	Logger fxevent.Logger
+}
+
+var _ Logger = (*FxeventLogger)(nil)
+
+// LogEvent dispatches the given pluginfx event to the adapted fxevent.Logger.
+func (l *FxeventLogger) LogEvent(event Event) {
+	switch fl := l.Logger.(type) {
+	case *fxevent.ConsoleLogger:
+		(&ConsoleLogger{W: fl.W}).LogEvent(event)
+		return
+
+	case *fxevent.ZapLogger:
+		(&ZapLogger{Logger: fl.Logger}).LogEvent(event)
+		return
+	}
+
+	switch e := event.(type) {
+	case *Opened:
+		l.Logger.LogEvent(&fxevent.Provided{
+			ConstructorName: "pluginfx.Open",
+			ModuleName:      e.Path,
+		})
+
+	case *OpenFailed:
+		l.Logger.LogEvent(&fxevent.Provided{
+			ConstructorName: "pluginfx.Open",
+			ModuleName:      e.Path,
+			Err:             e.Err,
+		})
+
+	case *SymbolBound:
+		if e.Kind == InvokeSymbol {
+			l.Logger.LogEvent(&fxevent.Invoking{
+				FunctionName: e.Name,
+				ModuleName:   e.Path,
+			})
+		} else {
+			l.Logger.LogEvent(&fxevent.Provided{
+				ConstructorName: e.Name,
+				ModuleName:      e.Path,
+				OutputTypeNames: outputTypeNames(e.Type),
+			})
+		}
+
+	case *SymbolMissing:
+		if e.Ignored {
+			l.Logger.LogEvent(&fxevent.Provided{
+				ConstructorName: "pluginfx.IgnoreMissing(" + e.Name + ")",
+				ModuleName:      e.Path,
+			})
+		} else {
+			l.Logger.LogEvent(&fxevent.Provided{
+				ConstructorName: e.Name,
+				ModuleName:      e.Path,
+				Err:             &MissingSymbolError{Name: e.Name},
+			})
+		}
+
+	case *SymbolRejected:
+		l.Logger.LogEvent(&fxevent.Provided{
+			ConstructorName: e.Name,
+			ModuleName:      e.Path,
+			Err:             e.Err,
+		})
+
+	case *LifecycleBound:
+		l.Logger.LogEvent(&fxevent.Invoking{
+			FunctionName: lifecycleFunctionName(e),
+			ModuleName:   e.Path,
+		})
+	}
+}
+
+// lifecycleFunctionName describes a bound lifecycle as a function name for fxevent.
+func lifecycleFunctionName(e *LifecycleBound) string {
+	var hooks []string
+	if len(e.OnStart) > 0 {
+		hooks = append(hooks, "OnStart="+e.OnStart)
+	}
+
+	if len(e.OnStop) > 0 {
+		hooks = append(hooks, "OnStop="+e.OnStop)
+	}
+
+	return "pluginfx.Lifecycle(" + strings.Join(hooks, ", ") + ")"
+}
+
+// outputTypeNames returns the names of the non-error result types of a function type.
+func outputTypeNames(ft reflect.Type) (names []string) {
+	if ft == nil || ft.Kind() != reflect.Func {
+		return
+	}
+
+	for i := 0; i < ft.NumOut(); i++ {
+		if ft.Out(i) != errType {
+			names = append(names, ft.Out(i).String())
+		}
+	}
+
+	return
+}
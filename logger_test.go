@@ -0,0 +1,233 @@
+package pluginfx
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fxeventRecorder is an fxevent.Logger that retains each event it receives.
+type fxeventRecorder struct {
+	events []fxevent.Event
+}
+
+func (fr *fxeventRecorder) LogEvent(e fxevent.Event) {
+	fr.events = append(fr.events, e)
+}
+
+type LoggerSuite struct {
+	suite.Suite
+}
+
+// allEvents returns one of each event type.
+func (suite *LoggerSuite) allEvents() []Event {
+	return []Event{
+		&Opened{Path: "test.so"},
+		&OpenFailed{Path: "test.so", Err: errors.New("expected")},
+		&SymbolBound{Path: "test.so", Name: "New", Kind: ProvideSymbol, Type: reflect.TypeOf(func() (int, error) { return 0, nil })},
+		&SymbolBound{Path: "test.so", Name: "Run", Kind: InvokeSymbol, Type: reflect.TypeOf(func() {})},
+		&SymbolMissing{Path: "test.so", Name: "Missing", Ignored: true},
+		&SymbolMissing{Path: "test.so", Name: "Missing", Ignored: false},
+		&SymbolRejected{Path: "test.so", Name: "Bad", Err: errors.New("expected")},
+		&LifecycleBound{Path: "test.so", OnStart: "Initialize", OnStop: "Shutdown"},
+		&Skipped{Path: "test.so", Err: errors.New("expected")},
+		&Configured{Path: "test.so", Name: "Configure"},
+		&Disabled{Path: "test.so", Condition: "env TEST present"},
+		&Selected{Path: "test-linux.so", Candidates: []string{"test-linux.so", "test.so"}},
+		&Fetched{URL: "http://example.com/test.so", Path: "/cache/test.so"},
+		&Fetched{URL: "http://example.com/test.so", Path: "/cache/test.so", Cached: true},
+		&Built{Source: "/src/test", Path: "/cache/test.so"},
+		&Built{Source: "/src/test", Path: "/cache/test.so", Cached: true},
+		&Duplicate{Path: "link.so", Original: "test.so"},
+		&Duplicate{Path: "link.so", Original: "test.so", Alias: true},
+	}
+}
+
+func (suite *LoggerSuite) TestConsoleLogger() {
+	for _, e := range suite.allEvents() {
+		var (
+			output bytes.Buffer
+			l      = &ConsoleLogger{W: &output}
+		)
+
+		l.LogEvent(e)
+		suite.Contains(output.String(), "[pluginfx]")
+	}
+}
+
+func (suite *LoggerSuite) TestZapLogger() {
+	for _, e := range suite.allEvents() {
+		var (
+			core, logs = observer.New(zap.DebugLevel)
+			l          = &ZapLogger{Logger: zap.New(core)}
+		)
+
+		l.LogEvent(e)
+		suite.Equal(1, logs.Len())
+	}
+}
+
+func (suite *LoggerSuite) TestFxeventLogger() {
+	suite.Run("ConsoleLogger", func() {
+		var (
+			output bytes.Buffer
+			l      = &FxeventLogger{Logger: &fxevent.ConsoleLogger{W: &output}}
+		)
+
+		l.LogEvent(&Opened{Path: "test.so"})
+		suite.Contains(output.String(), "test.so")
+	})
+
+	suite.Run("ZapLogger", func() {
+		var (
+			core, logs = observer.New(zap.DebugLevel)
+			l          = &FxeventLogger{Logger: &fxevent.ZapLogger{Logger: zap.New(core)}}
+		)
+
+		l.LogEvent(&Opened{Path: "test.so"})
+		suite.Equal(1, logs.Len())
+	})
+
+	suite.Run("Translated", func() {
+		var (
+			recorder fxeventRecorder
+			l        = &FxeventLogger{Logger: &recorder}
+		)
+
+		for _, e := range suite.allEvents() {
+			l.LogEvent(e)
+		}
+
+		suite.Require().Len(recorder.events, 8)
+
+		suite.Equal(
+			&fxevent.Provided{
+				ConstructorName: "pluginfx.Open",
+				ModuleName:      "test.so",
+			},
+			recorder.events[0],
+		)
+
+		suite.Require().IsType((*fxevent.Provided)(nil), recorder.events[1])
+		suite.Equal("pluginfx.Open", recorder.events[1].(*fxevent.Provided).ConstructorName)
+		suite.Error(recorder.events[1].(*fxevent.Provided).Err)
+		suite.Equal("test.so", recorder.events[1].(*fxevent.Provided).ModuleName)
+
+		suite.Require().IsType((*fxevent.Provided)(nil), recorder.events[2])
+		suite.Equal([]string{"int"}, recorder.events[2].(*fxevent.Provided).OutputTypeNames)
+
+		suite.Require().IsType((*fxevent.Invoking)(nil), recorder.events[3])
+		suite.Equal("Run", recorder.events[3].(*fxevent.Invoking).FunctionName)
+
+		// an ignored missing symbol is reported without an error
+		suite.Equal(
+			&fxevent.Provided{
+				ConstructorName: "pluginfx.IgnoreMissing(Missing)",
+				ModuleName:      "test.so",
+			},
+			recorder.events[4],
+		)
+
+		suite.Require().IsType((*fxevent.Provided)(nil), recorder.events[5])
+		suite.Equal("Missing", recorder.events[5].(*fxevent.Provided).ConstructorName)
+		suite.True(IsMissingSymbolError(recorder.events[5].(*fxevent.Provided).Err))
+
+		suite.Require().IsType((*fxevent.Provided)(nil), recorder.events[6])
+		suite.Error(recorder.events[6].(*fxevent.Provided).Err)
+
+		suite.Equal(
+			&fxevent.Invoking{
+				FunctionName: "pluginfx.Lifecycle(OnStart=Initialize, OnStop=Shutdown)",
+				ModuleName:   "test.so",
+			},
+			recorder.events[7],
+		)
+	})
+
+	suite.Run("Plugin", func() {
+		var (
+			recorder fxeventRecorder
+			app      = fx.New(
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Symbols: Symbols{
+						Names: []interface{}{"New", "Missing"},
+					},
+					Lifecycle: Lifecycle{
+						OnStop: "Shutdown",
+					},
+					Logger: &FxeventLogger{Logger: &recorder},
+				}.Provide(),
+			)
+		)
+
+		suite.Error(app.Err())
+		suite.Equal(
+			[]fxevent.Event{
+				&fxevent.Provided{
+					ConstructorName: "pluginfx.Open",
+					ModuleName:      sampleOpenPath,
+				},
+				&fxevent.Provided{
+					ConstructorName: "New",
+					ModuleName:      sampleOpenPath,
+					OutputTypeNames: []string{"float64"},
+				},
+				&fxevent.Provided{
+					ConstructorName: "Missing",
+					ModuleName:      sampleOpenPath,
+					Err:             &MissingSymbolError{Name: "Missing"},
+				},
+				&fxevent.Invoking{
+					FunctionName: "pluginfx.Lifecycle(OnStop=Shutdown)",
+					ModuleName:   sampleOpenPath,
+				},
+			},
+			recorder.events,
+		)
+	})
+
+	suite.Run("IgnoreMissing", func() {
+		var (
+			recorder fxeventRecorder
+			app      = fx.New(
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Symbols: Symbols{
+						Names:         []interface{}{"Missing"},
+						IgnoreMissing: true,
+					},
+					Logger: &FxeventLogger{Logger: &recorder},
+				}.Provide(),
+			)
+		)
+
+		suite.NoError(app.Err())
+		suite.Equal(
+			[]fxevent.Event{
+				&fxevent.Provided{
+					ConstructorName: "pluginfx.Open",
+					ModuleName:      sampleOpenPath,
+				},
+				&fxevent.Provided{
+					ConstructorName: "pluginfx.IgnoreMissing(Missing)",
+					ModuleName:      sampleOpenPath,
+				},
+			},
+			recorder.events,
+		)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	suite.Run(t, new(LoggerSuite))
+}
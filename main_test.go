@@ -7,25 +7,67 @@ import (
 	"testing"
 )
 
-const samplePath = "sample.so"
+const (
+	samplePath = "sample.so"
 
-func TestMain(m *testing.M) {
-	cmd := exec.Command("go", "build", "-buildmode=plugin", "./sample")
+	// embeddedPath is a second plugin, used to test loading from an fs.FS.  It must be a
+	// distinct plugin, as the Go runtime refuses to load a copy of a plugin that is already loaded.
+	embeddedPath = "testdata/embedded.so"
+)
+
+// sampleOpenPath is the absolute path that P and S actually open for samplePath.
+var sampleOpenPath = absolute(samplePath)
+
+// testCacheDir is the cache directory shared by all tests that load plugins from an fs.FS.
+// Sharing this directory ensures that each copy of the embedded plugin has the same path.
+var testCacheDir string
+
+func buildPlugin(pkg, output string) error {
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", output, pkg)
 	fmt.Println(cmd)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
+	return cmd.Run()
+}
+
+func TestMain(m *testing.M) {
+	// tests expect relative paths to resolve against the working directory
+	os.Unsetenv(SearchPathEnv)
+
+	if err := buildPlugin("./sample", samplePath); err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to build sample plugin: %s\n", err)
 		os.Exit(1)
 	}
 
-	var code int
+	if err := buildPlugin("./testdata/embedded", embeddedPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to build embedded plugin: %s\n", err)
+		os.Remove(samplePath)
+		os.Exit(1)
+	}
+
+	var (
+		code int
+		err  error
+	)
+
+	testCacheDir, err = os.MkdirTemp("", "pluginfx-test-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create cache directory: %s\n", err)
+		code = 1
+	}
+
 	defer func() {
 		os.Remove(samplePath)
+		os.Remove(embeddedPath)
+		if len(testCacheDir) > 0 {
+			os.RemoveAll(testCacheDir)
+		}
+
 		os.Exit(code)
 	}()
 
-	code = m.Run()
+	if code == 0 {
+		code = m.Run()
+	}
 }
@@ -0,0 +1,301 @@
+package pluginfx
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// OpenCounter is the counter incremented each time a plugin is opened,
+	// regardless of whether the open succeeded.
+	OpenCounter = "pluginfx_open_total"
+
+	// OpenErrorCounter is the counter incremented each time a plugin could not be opened.
+	OpenErrorCounter = "pluginfx_open_errors_total"
+
+	// OpenDuration is the histogram of the time taken to open plugins.
+	OpenDuration = "pluginfx_open_duration_seconds"
+
+	// CallCounter is the counter incremented each time a constructor or invoke
+	// function from a plugin is called by the enclosing fx.App.
+	CallCounter = "pluginfx_call_total"
+
+	// CallErrorCounter is the counter incremented each time a constructor or invoke
+	// function from a plugin returns an error.
+	CallErrorCounter = "pluginfx_call_errors_total"
+
+	// CallDuration is the histogram of the time taken by constructors and
+	// invoke functions from plugins.
+	CallDuration = "pluginfx_call_duration_seconds"
+
+	// HookCounter is the counter incremented each time an OnStart or OnStop
+	// callback from a plugin is executed.
+	HookCounter = "pluginfx_hook_total"
+
+	// HookErrorCounter is the counter incremented each time an OnStart or OnStop
+	// callback from a plugin returns an error.
+	HookErrorCounter = "pluginfx_hook_errors_total"
+
+	// OnStartDuration is the histogram of the time taken by OnStart callbacks.
+	OnStartDuration = "pluginfx_onstart_duration_seconds"
+
+	// OnStopDuration is the histogram of the time taken by OnStop callbacks.
+	OnStopDuration = "pluginfx_onstop_duration_seconds"
+)
+
+// CounterNames returns the names of all counters reported by this package.
+func CounterNames() []string {
+	return []string{
+		OpenCounter,
+		OpenErrorCounter,
+		CallCounter,
+		CallErrorCounter,
+		HookCounter,
+		HookErrorCounter,
+	}
+}
+
+// HistogramNames returns the names of all histograms reported by this package.
+func HistogramNames() []string {
+	return []string{
+		OpenDuration,
+		CallDuration,
+		OnStartDuration,
+		OnStopDuration,
+	}
+}
+
+// Labels identifies what a measurement applies to.
+type Labels struct {
+	// Path is the plugin path.  This field is empty when symbols are loaded
+	// directly via Symbols.Load or Lifecycle.Bind.
+	Path string
+
+	// Symbol is the symbol name.  This field is empty for measurements that
+	// apply to the plugin as a whole, such as opening it.
+	Symbol string
+}
+
+// Metrics is the sink for measurements taken while plugins are loaded and run.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Inc increments the named counter.
+	Inc(name string, labels Labels)
+
+	// Observe records a duration in the named histogram.
+	Observe(name string, labels Labels, d time.Duration)
+}
+
+// MetricKey identifies a single time series within MemoryMetrics.
+type MetricKey struct {
+	Name   string
+	Labels Labels
+}
+
+// MemoryMetrics is an in-memory Metrics implementation, useful for testing.
+// The zero value of this type is ready to use.
+type MemoryMetrics struct {
+	lock       sync.Mutex
+	counters   map[MetricKey]int
+	histograms map[MetricKey][]time.Duration
+}
+
+var _ Metrics = (*MemoryMetrics)(nil)
+
+// Inc implements Metrics.
+func (mm *MemoryMetrics) Inc(name string, labels Labels) {
+	mm.lock.Lock()
+	if mm.counters == nil {
+		mm.counters = make(map[MetricKey]int)
+	}
+
+	mm.counters[MetricKey{Name: name, Labels: labels}]++
+	mm.lock.Unlock()
+}
+
+// Observe implements Metrics.
+func (mm *MemoryMetrics) Observe(name string, labels Labels, d time.Duration) {
+	mm.lock.Lock()
+	if mm.histograms == nil {
+		mm.histograms = make(map[MetricKey][]time.Duration)
+	}
+
+	key := MetricKey{Name: name, Labels: labels}
+	mm.histograms[key] = append(mm.histograms[key], d)
+	mm.lock.Unlock()
+}
+
+// Counter returns the current value of a counter.
+func (mm *MemoryMetrics) Counter(name string, labels Labels) int {
+	mm.lock.Lock()
+	defer mm.lock.Unlock()
+	return mm.counters[MetricKey{Name: name, Labels: labels}]
+}
+
+// Durations returns a copy of the durations observed for a histogram.
+func (mm *MemoryMetrics) Durations(name string, labels Labels) []time.Duration {
+	mm.lock.Lock()
+	defer mm.lock.Unlock()
+
+	observed := mm.histograms[MetricKey{Name: name, Labels: labels}]
+	if len(observed) == 0 {
+		return nil
+	}
+
+	return append([]time.Duration(nil), observed...)
+}
+
+// Keys returns the keys of every counter and histogram that has been recorded,
+// sorted by name and then labels.
+func (mm *MemoryMetrics) Keys() []MetricKey {
+	mm.lock.Lock()
+	keys := make([]MetricKey, 0, len(mm.counters)+len(mm.histograms))
+	for k := range mm.counters {
+		keys = append(keys, k)
+	}
+
+	for k := range mm.histograms {
+		keys = append(keys, k)
+	}
+
+	mm.lock.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		switch {
+		case keys[i].Name != keys[j].Name:
+			return keys[i].Name < keys[j].Name
+
+		case keys[i].Labels.Path != keys[j].Labels.Path:
+			return keys[i].Labels.Path < keys[j].Labels.Path
+
+		default:
+			return keys[i].Labels.Symbol < keys[j].Labels.Symbol
+		}
+	})
+
+	return keys
+}
+
+// count increments the given counter, along with the given error counter if err is not nil.
+func (l loader) count(counter, errorCounter string, labels Labels, err error) {
+	if l.metrics != nil {
+		l.metrics.Inc(counter, labels)
+		if err != nil {
+			l.metrics.Inc(errorCounter, labels)
+		}
+	}
+}
+
+// observe records the time elapsed since start in the given histogram.
+func (l loader) observe(histogram string, labels Labels, start time.Time) {
+	if l.metrics != nil {
+		l.metrics.Observe(histogram, labels, time.Since(start))
+	}
+}
+
+// open opens the plugin at this loader's path, reporting the outcome.
+func (l loader) open() (Plugin, error) {
+	var (
+		labels = Labels{Path: l.path}
+		start  = time.Now()
+	)
+
+	var (
+		p   Plugin
+		err error
+	)
+
+	if l.preflight {
+		err = Preflight(l.path)
+	}
+
+	if err == nil {
+		ctx, cancel := l.openContext()
+		p, err = OpenContext(ctx, l.path)
+		cancel()
+	}
+
+	l.observe(OpenDuration, labels, start)
+	l.count(OpenCounter, OpenErrorCounter, labels, err)
+
+	if err == nil {
+		// build information is read once, so that reporting it never touches the file again
+		if l.entry != nil {
+			l.entry.setBuild(readBuildInfo(l.path))
+		}
+
+		l.log(&Opened{Path: l.path})
+	} else {
+		l.log(&OpenFailed{Path: l.path, Err: err})
+	}
+
+	return p, err
+}
+
+// openContext returns the context that limits how long opening this loader's plugin takes.
+func (l loader) openContext() (context.Context, context.CancelFunc) {
+	switch {
+	case !l.deadline.IsZero():
+		return context.WithDeadline(context.Background(), l.deadline)
+
+	case l.timeout > 0:
+		return context.WithTimeout(context.Background(), l.timeout)
+
+	default:
+		return context.Background(), func() {}
+	}
+}
+
+// instrumentFunc decorates a plugin function so that each call is measured.
+// The returned function has the same type as f, so that it can be used anywhere
+// the original could be, but fx and dig only know it as reflect.makeFuncStub.
+// If no Metrics are configured, f is returned as is.
+func (l loader) instrumentFunc(name string, f reflect.Value) reflect.Value {
+	if l.metrics == nil {
+		return f
+	}
+
+	var (
+		ft     = f.Type()
+		labels = Labels{Path: l.path, Symbol: name}
+	)
+
+	return reflect.MakeFunc(ft, func(args []reflect.Value) (results []reflect.Value) {
+		start := time.Now()
+		if ft.IsVariadic() {
+			results = f.CallSlice(args)
+		} else {
+			results = f.Call(args)
+		}
+
+		l.observe(CallDuration, labels, start)
+
+		var err error
+		if n := len(results); n > 0 && ft.Out(n-1) == errType && !results[n-1].IsNil() {
+			err = results[n-1].Interface().(error)
+		}
+
+		l.count(CallCounter, CallErrorCounter, labels, err)
+		return
+	})
+}
+
+// instrumentHook decorates a lifecycle callback so that each execution is measured.
+// If no Metrics are configured, callback is returned as is.
+func (l loader) instrumentHook(name, histogram string, callback func(context.Context) error) func(context.Context) error {
+	if l.metrics == nil || callback == nil {
+		return callback
+	}
+
+	labels := Labels{Path: l.path, Symbol: name}
+	return func(ctx context.Context) error {
+		start := time.Now()
+		err := callback(ctx)
+		l.observe(histogram, labels, start)
+		l.count(HookCounter, HookErrorCounter, labels, err)
+		return err
+	}
+}
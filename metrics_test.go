@@ -0,0 +1,236 @@
+package pluginfx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
+	"go.uber.org/fx/fxtest"
+)
+
+type MetricsSuite struct {
+	PluginfxSuite
+}
+
+func (suite *MetricsSuite) TestMemoryMetrics() {
+	var (
+		mm     MemoryMetrics
+		labels = Labels{Path: "test.so", Symbol: "New"}
+	)
+
+	suite.Zero(mm.Counter(CallCounter, labels))
+	suite.Nil(mm.Durations(CallDuration, labels))
+	suite.Empty(mm.Keys())
+
+	mm.Inc(CallCounter, labels)
+	mm.Inc(CallCounter, labels)
+	mm.Inc(OpenCounter, Labels{Path: "test.so"})
+	mm.Observe(CallDuration, labels, time.Second)
+
+	suite.Equal(2, mm.Counter(CallCounter, labels))
+	suite.Equal([]time.Duration{time.Second}, mm.Durations(CallDuration, labels))
+	suite.Equal(
+		[]MetricKey{
+			{Name: CallDuration, Labels: labels},
+			{Name: CallCounter, Labels: labels},
+			{Name: OpenCounter, Labels: Labels{Path: "test.so"}},
+		},
+		mm.Keys(),
+	)
+}
+
+func (suite *MetricsSuite) TestP() {
+	suite.Run("Success", func() {
+		var (
+			mm    MemoryMetrics
+			value float64
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+					Lifecycle: Lifecycle{
+						OnStart: "Initialize",
+						OnStop:  "Shutdown",
+					},
+					Metrics: &mm,
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(expectedNewValue, value)
+
+		open := Labels{Path: sampleOpenPath}
+		suite.Equal(1, mm.Counter(OpenCounter, open))
+		suite.Zero(mm.Counter(OpenErrorCounter, open))
+		suite.Len(mm.Durations(OpenDuration, open), 1)
+
+		newLabels := Labels{Path: sampleOpenPath, Symbol: "New"}
+		suite.Equal(1, mm.Counter(CallCounter, newLabels))
+		suite.Zero(mm.Counter(CallErrorCounter, newLabels))
+		suite.Len(mm.Durations(CallDuration, newLabels), 1)
+
+		onStart := Labels{Path: sampleOpenPath, Symbol: "Initialize"}
+		suite.Equal(1, mm.Counter(HookCounter, onStart))
+		suite.Len(mm.Durations(OnStartDuration, onStart), 1)
+
+		onStop := Labels{Path: sampleOpenPath, Symbol: "Shutdown"}
+		suite.Equal(1, mm.Counter(HookCounter, onStop))
+		suite.Len(mm.Durations(OnStopDuration, onStop), 1)
+	})
+
+	suite.Run("SymbolNames", func() {
+		// fx names measured functions after their wrapper, so symbols are logged by name instead
+		var (
+			mm       MemoryMetrics
+			recorder fxeventRecorder
+			value    float64
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+					Logger:  &FxeventLogger{Logger: &recorder},
+					Metrics: &mm,
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(expectedNewValue, value)
+		suite.Contains(
+			recorder.events,
+			&fxevent.Provided{
+				ConstructorName: "New",
+				ModuleName:      sampleOpenPath,
+				OutputTypeNames: []string{"float64"},
+			},
+		)
+	})
+
+	suite.Run("OpenError", func() {
+		var mm MemoryMetrics
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      "/no/such/plugin.123",
+				Metrics:   &mm,
+			}.Provide(),
+		)
+
+		suite.Error(app.Err())
+
+		labels := Labels{Path: "/no/such/plugin.123"}
+		suite.Equal(1, mm.Counter(OpenCounter, labels))
+		suite.Equal(1, mm.Counter(OpenErrorCounter, labels))
+		suite.Len(mm.Durations(OpenDuration, labels), 1)
+	})
+
+	suite.Run("ConstructorError", func() {
+		var (
+			mm MemoryMetrics
+
+			app = fx.New(
+				S{
+					Paths: []string{samplePath},
+					Symbols: Symbols{
+						Names: []interface{}{"AlwaysErrors"},
+					},
+					Metrics: &mm,
+				}.Provide(),
+				fx.Invoke(func(string) {}),
+			)
+		)
+
+		suite.Error(app.Err())
+
+		labels := Labels{Path: sampleOpenPath, Symbol: "AlwaysErrors"}
+		suite.Equal(1, mm.Counter(CallCounter, labels))
+		suite.Equal(1, mm.Counter(CallErrorCounter, labels))
+	})
+}
+
+func (suite *MetricsSuite) TestDirect() {
+	var (
+		mm      MemoryMetrics
+		value   float64
+		symbols = NewSymbolMap(map[string]interface{}{
+			"New":        func() (float64, error) { return expectedNewValue, nil },
+			"Initialize": func() {},
+			"Shutdown":   func(context.Context) error { return nil },
+		})
+
+		app = fxtest.New(
+			suite.T(),
+			Symbols{
+				Names:   []interface{}{"New"},
+				Metrics: &mm,
+			}.Load(symbols),
+			Lifecycle{
+				OnStart: "Initialize",
+				OnStop:  "Shutdown",
+				Metrics: &mm,
+			}.Bind(symbols),
+			fx.Populate(&value),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Equal(expectedNewValue, value)
+
+	suite.Equal(1, mm.Counter(CallCounter, Labels{Symbol: "New"}))
+	suite.Len(mm.Durations(CallDuration, Labels{Symbol: "New"}), 1)
+	suite.Len(mm.Durations(OnStartDuration, Labels{Symbol: "Initialize"}), 1)
+	suite.Len(mm.Durations(OnStopDuration, Labels{Symbol: "Shutdown"}), 1)
+}
+
+func (suite *MetricsSuite) TestInstrument() {
+	var (
+		mm MemoryMetrics
+		l  = loader{path: "test.so", metrics: &mm}
+
+		labels = Labels{Path: "test.so", Symbol: "Variadic"}
+		sum    int
+	)
+
+	f := l.instrumentFunc("Variadic", reflect.ValueOf(func(values ...int) {
+		for _, v := range values {
+			sum += v
+		}
+	}))
+
+	f.Interface().(func(...int))(1, 2, 3)
+	suite.Equal(6, sum)
+	suite.Equal(1, mm.Counter(CallCounter, labels))
+
+	hook := l.instrumentHook("Hook", OnStartDuration, func(context.Context) error {
+		return errors.New("expected")
+	})
+
+	suite.Error(hook(context.Background()))
+	suite.Equal(1, mm.Counter(HookErrorCounter, Labels{Path: "test.so", Symbol: "Hook"}))
+	suite.Nil(l.instrumentHook("Nil", OnStopDuration, nil))
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
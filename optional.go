@@ -0,0 +1,47 @@
+package pluginfx
+
+import "go.uber.org/fx"
+
+// OptionalPlugin is the component provided for an optional plugin in place of
+// the Plugin itself.  This allows consumers to check whether the plugin is present.
+type OptionalPlugin struct {
+	// Plugin is the loaded plugin.  This field is nil if the plugin was skipped.
+	Plugin Plugin
+
+	// Err is the reason the plugin was skipped.  This field is nil if the plugin was loaded.
+	Err error
+}
+
+// Present tests if the plugin was loaded and bound to the enclosing fx.App.
+func (op OptionalPlugin) Present() bool {
+	return op.Plugin != nil
+}
+
+// provideOptional handles the outcome of loading an optional plugin.  If err is not nil,
+// the plugin is reported as skipped.  In all cases, an OptionalPlugin component is emitted
+// unless this P is anonymous.
+func (p P) provideOptional(l loader, plugin Plugin, err error) fx.Option {
+	if err != nil {
+		l.log(&Skipped{Path: l.path, Err: err})
+		if p.OnError != nil {
+			p.OnError(err)
+		}
+	}
+
+	op := OptionalPlugin{
+		Plugin: plugin,
+		Err:    err,
+	}
+
+	if p.Anonymous {
+		return fx.Options()
+	}
+
+	return fx.Provide(
+		fx.Annotated{
+			Name:   p.Name,
+			Group:  p.Group,
+			Target: func() OptionalPlugin { return op },
+		},
+	)
+}
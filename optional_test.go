@@ -0,0 +1,189 @@
+package pluginfx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type OptionalSuite struct {
+	PluginfxSuite
+}
+
+func (suite *OptionalSuite) TestOpenFailure() {
+	var (
+		onError  []error
+		recorder eventRecorder
+		op       OptionalPlugin
+		registry *Registry
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Path:     "/no/such/plugin.123",
+				Optional: true,
+				OnError:  func(err error) { onError = append(onError, err) },
+				Logger:   &recorder,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&op, &registry),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.False(op.Present())
+	suite.openError("/no/such/plugin.123", op.Err)
+
+	suite.Require().Len(onError, 1)
+	suite.Equal(op.Err, onError[0])
+
+	var pe *PluginError
+	suite.Require().True(errors.As(onError[0], &pe))
+	suite.Equal(PhaseOpen, pe.Phase)
+
+	suite.Require().Len(recorder.events, 2)
+	suite.IsType((*OpenFailed)(nil), recorder.events[0])
+	suite.Equal(&Skipped{Path: "/no/such/plugin.123", Err: op.Err}, recorder.events[1])
+
+	record, ok := registry.Get("/no/such/plugin.123")
+	suite.Require().True(ok)
+	suite.Equal(StatusSkipped, record.Status)
+	suite.Error(record.Err)
+}
+
+func (suite *OptionalSuite) TestSymbolFailure() {
+	var (
+		onError []error
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Name:     "sample",
+				Path:     samplePath,
+				Optional: true,
+				OnError:  func(err error) { onError = append(onError, err) },
+				Symbols: Symbols{
+					Names: []interface{}{"New", "Missing"},
+				},
+				Lifecycle: Lifecycle{
+					OnStart: "Initialize",
+				},
+			}.Provide(),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					Plugin OptionalPlugin `name:"sample"`
+					Value  float64        `optional:"true"`
+				}) {
+					suite.False(in.Plugin.Present())
+					suite.True(IsMissingSymbolError(in.Plugin.Err))
+
+					// none of the plugin's symbols should have been bound
+					suite.Zero(in.Value)
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Len(onError, 1)
+}
+
+func (suite *OptionalSuite) TestSuccess() {
+	var (
+		value float64
+		op    OptionalPlugin
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Path:     samplePath,
+				Optional: true,
+				OnError: func(err error) {
+					suite.Fail("OnError should not have been called", "error: %s", err)
+				},
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			fx.Populate(&value, &op),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.Equal(expectedNewValue, value)
+	suite.True(op.Present())
+	suite.NoError(op.Err)
+}
+
+func (suite *OptionalSuite) TestAnonymous() {
+	app := fxtest.New(
+		suite.T(),
+		P{
+			Anonymous: true,
+			Path:      "/no/such/plugin.123",
+			Optional:  true,
+		}.Provide(),
+		fx.Invoke(
+			func(in struct {
+				fx.In
+				Plugin *OptionalPlugin `optional:"true"`
+			}) {
+				suite.Nil(in.Plugin)
+			},
+		),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+}
+
+func (suite *OptionalSuite) TestS() {
+	var (
+		skipped int
+		value   float64
+
+		app = fxtest.New(
+			suite.T(),
+			S{
+				Group:    "plugins",
+				Paths:    []string{samplePath, "/no/such/*.so", "main_test.go"},
+				Optional: true,
+				OnError:  func(error) { skipped++ },
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			fx.Populate(&value),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					Plugins []OptionalPlugin `group:"plugins"`
+				}) {
+					suite.Len(in.Plugins, 2)
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+
+	suite.Equal(expectedNewValue, value)
+	suite.Equal(1, skipped)
+}
+
+func TestOptional(t *testing.T) {
+	suite.Run(t, new(OptionalSuite))
+}
@@ -0,0 +1,87 @@
+package pluginfx
+
+import (
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// lockedLogger is a Logger that serializes calls to another Logger.
+type lockedLogger struct {
+	lock   *sync.Mutex
+	logger Logger
+}
+
+func (ll *lockedLogger) LogEvent(e Event) {
+	ll.lock.Lock()
+	defer ll.lock.Unlock()
+	ll.logger.LogEvent(e)
+}
+
+// serialize returns a copy of this P whose Logger and OnError may be called from
+// multiple goroutines.  Each is guarded by the same lock, so neither is ever called
+// concurrently with itself or the other.
+func (p P) serialize() P {
+	lock := new(sync.Mutex)
+	if p.Logger != nil {
+		p.Logger = &lockedLogger{lock: lock, logger: p.Logger}
+	}
+
+	if onError := p.OnError; onError != nil {
+		p.OnError = func(err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			onError(err)
+		}
+	}
+
+	return p
+}
+
+// done returns a task whose option has already been computed.
+func done(o fx.Option) func() fx.Option {
+	return func() fx.Option {
+		return o
+	}
+}
+
+// parallel runs tasks on at most n goroutines, returning the result of each task in
+// the same order as tasks.  If n is less than 2, tasks are run in turn on the calling
+// goroutine.
+func parallel[T any](n int, tasks []func() T) []T {
+	results := make([]T, len(tasks))
+	if n < 2 || len(tasks) < 2 {
+		for i, t := range tasks {
+			results[i] = t()
+		}
+
+		return results
+	}
+
+	if n > len(tasks) {
+		n = len(tasks)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		next = make(chan int)
+	)
+
+	wg.Add(n)
+	for w := 0; w < n; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = tasks[i]()
+			}
+		}()
+	}
+
+	for i := range tasks {
+		next <- i
+	}
+
+	close(next)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,209 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
+)
+
+// overlapLogger detects concurrent calls to LogEvent.
+type overlapLogger struct {
+	active  int32
+	overlap int32
+	count   int32
+}
+
+func (ol *overlapLogger) LogEvent(Event) {
+	if atomic.AddInt32(&ol.active, 1) > 1 {
+		atomic.StoreInt32(&ol.overlap, 1)
+	}
+
+	time.Sleep(time.Millisecond)
+	atomic.AddInt32(&ol.count, 1)
+	atomic.AddInt32(&ol.active, -1)
+}
+
+type ParallelSuite struct {
+	PluginfxSuite
+}
+
+// tasks creates tasks that each return their index, while tracking how many run at once.
+func (suite *ParallelSuite) tasks(count int, maxActive *int32) []func() int {
+	var (
+		active int32
+		tasks  = make([]func() int, count)
+	)
+
+	for i := range tasks {
+		i := i
+		tasks[i] = func() int {
+			n := atomic.AddInt32(&active, 1)
+			for {
+				m := atomic.LoadInt32(maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(maxActive, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			return i
+		}
+	}
+
+	return tasks
+}
+
+func (suite *ParallelSuite) TestParallel() {
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	for _, n := range []int{-1, 0, 1} {
+		var maxActive int32
+		suite.Equal(expected, parallel(n, suite.tasks(len(expected), &maxActive)))
+		suite.Equal(int32(1), maxActive)
+	}
+
+	suite.Run("Bounded", func() {
+		var maxActive int32
+		suite.Equal(expected, parallel(3, suite.tasks(len(expected), &maxActive)))
+		suite.Greater(maxActive, int32(1))
+		suite.LessOrEqual(maxActive, int32(3))
+	})
+
+	suite.Run("MoreWorkersThanTasks", func() {
+		var maxActive int32
+		suite.Equal([]int{0, 1}, parallel(10, suite.tasks(2, &maxActive)))
+		suite.LessOrEqual(maxActive, int32(2))
+	})
+
+	suite.Run("Empty", func() {
+		suite.Empty(parallel(4, []func() int{}))
+	})
+}
+
+func (suite *ParallelSuite) TestSerialize() {
+	var (
+		logger  overlapLogger
+		errs    []error
+		p       = P{Logger: &logger, OnError: func(err error) { errs = append(errs, err) }}.serialize()
+		wg      sync.WaitGroup
+		workers = 8
+	)
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			p.Logger.LogEvent(&Opened{Path: "test.so"})
+			p.OnError(errors.New("expected"))
+		}()
+	}
+
+	wg.Wait()
+	suite.Zero(logger.overlap)
+	suite.Equal(int32(workers), logger.count)
+	suite.Len(errs, workers)
+
+	suite.Run("Nil", func() {
+		p := P{}.serialize()
+		suite.Nil(p.Logger)
+		suite.Nil(p.OnError)
+	})
+}
+
+func (suite *ParallelSuite) TestS() {
+	var (
+		dir   = suite.T().TempDir()
+		bad1  = filepath.Join(dir, "bad1.so")
+		bad2  = filepath.Join(dir, "bad2.so")
+		paths = []string{bad1, samplePath, bad2}
+	)
+
+	suite.Require().NoError(os.WriteFile(bad1, []byte("not a plugin"), 0600))
+	suite.Require().NoError(os.WriteFile(bad2, []byte("not a plugin"), 0600))
+
+	suite.Run("Optional", func() {
+		var (
+			logger   overlapLogger
+			skipped  []error
+			registry *Registry
+			plugins  []OptionalPlugin
+
+			app = fxtest.New(
+				suite.T(),
+				S{
+					Group:       "plugins",
+					Paths:       paths,
+					Concurrency: 4,
+					Optional:    true,
+					Logger:      &logger,
+					OnError:     func(err error) { skipped = append(skipped, err) },
+				}.Provide(),
+				ProvideRegistry(),
+				fx.Populate(&registry),
+				fx.Invoke(
+					func(in struct {
+						fx.In
+						Plugins []OptionalPlugin `group:"plugins"`
+					}) {
+						plugins = in.Plugins
+					},
+				),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.Zero(logger.overlap)
+		suite.Len(skipped, 2)
+		suite.Len(plugins, len(paths))
+
+		var recorded []string
+		for _, r := range registry.Records() {
+			recorded = append(recorded, r.Path)
+		}
+
+		suite.ElementsMatch(paths, recorded)
+	})
+
+	suite.Run("Errors", func() {
+		// errors are reported in the order of Paths, regardless of which plugin fails first
+		var bad []string
+		for i := 0; i < 8; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("ordered%d.so", i))
+			suite.Require().NoError(os.WriteFile(path, []byte("not a plugin"), 0600))
+			bad = append(bad, path)
+		}
+
+		app := fx.New(
+			S{
+				Paths:       append(append([]string{}, paths...), bad...),
+				Concurrency: 4,
+			}.Provide(),
+		)
+
+		var failed []string
+		for _, err := range multierr.Errors(app.Err()) {
+			var oe *OpenError
+			suite.Require().True(errors.As(err, &oe))
+			failed = append(failed, oe.Path)
+		}
+
+		suite.Equal(append([]string{bad1, bad2}, bad...), failed)
+	})
+}
+
+func TestParallel(t *testing.T) {
+	suite.Run(t, new(ParallelSuite))
+}
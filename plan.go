@@ -0,0 +1,277 @@
+package pluginfx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+)
+
+// Step describes how a single plugin symbol will be bound to an enclosing fx.App.
+type Step struct {
+	// Symbol is the name of the plugin symbol.
+	Symbol string
+
+	// Kind is how the symbol will be bound.
+	Kind SymbolKind
+
+	// Type is the Go type of the symbol.  This field is nil if the
+	// symbol could not be found.
+	Type reflect.Type
+
+	// Params are the parameter types of the symbol, if it is a function.
+	Params []reflect.Type
+
+	// Results are the result types of the symbol, if it is a function.
+	Results []reflect.Type
+
+	// Name is the component name for a TargetSymbol.
+	Name string
+
+	// Group is the value group for a TargetSymbol.
+	Group string
+
+	// Err is any error that prevents this step from being bound.  If Ignored is
+	// false, a non-nil Err will shortcircuit application startup.
+	Err error
+
+	// Ignored indicates that this step contributes nothing to the enclosing fx.App,
+	// because the symbol was missing and IgnoreMissing was set.
+	Ignored bool
+
+	value    reflect.Value
+	callback func(context.Context) error
+}
+
+// setType sets this step's Type, along with Params and Results if t is a function type.
+func (st *Step) setType(t reflect.Type) {
+	st.Type = t
+	st.Params, st.Results = nil, nil
+	if t.Kind() != reflect.Func {
+		return
+	}
+
+	for i := 0; i < t.NumIn(); i++ {
+		st.Params = append(st.Params, t.In(i))
+	}
+
+	for i := 0; i < t.NumOut(); i++ {
+		st.Results = append(st.Results, t.Out(i))
+	}
+}
+
+// String returns a single-line description of this step.
+func (st Step) String() string {
+	var o strings.Builder
+	o.WriteString(st.Kind.String())
+	o.WriteRune(' ')
+	o.WriteString(st.Symbol)
+
+	if st.Type != nil {
+		o.WriteRune(' ')
+		o.WriteString(st.Type.String())
+	}
+
+	if len(st.Name) > 0 {
+		fmt.Fprintf(&o, " name=%q", st.Name)
+	}
+
+	if len(st.Group) > 0 {
+		fmt.Fprintf(&o, " group=%q", st.Group)
+	}
+
+	switch {
+	case st.Ignored:
+		o.WriteString(" (ignored: missing)")
+
+	case st.Err != nil:
+		fmt.Fprintf(&o, " (error: %s)", st.Err)
+	}
+
+	return o.String()
+}
+
+// Plan is the structured description of how a plugin's symbols will be bound to
+// an enclosing fx.App.  A Plan can be examined or printed prior to being converted
+// into fx.Options.
+type Plan struct {
+	// Steps are the individual symbols, in the order they will be bound.
+	Steps []Step
+}
+
+// NewPlan resolves a plugin against both Symbols and Lifecycle.
+func NewPlan(p Plugin, s Symbols, lc Lifecycle) *Plan {
+	plan := s.Plan(p)
+	plan.Steps = append(plan.Steps, lc.Plan(p).Steps...)
+	return plan
+}
+
+// Err returns the errors from any steps that would shortcircuit application startup,
+// combined with go.uber.org/multierr.  If there are no such steps, this method returns nil.
+func (plan *Plan) Err() (err error) {
+	for _, st := range plan.Steps {
+		if !st.Ignored {
+			err = multierr.Append(err, st.Err)
+		}
+	}
+
+	return
+}
+
+// String returns a multi-line description of this plan, one line per step.
+func (plan *Plan) String() string {
+	var o strings.Builder
+	for i, st := range plan.Steps {
+		if i > 0 {
+			o.WriteRune('\n')
+		}
+
+		o.WriteString(st.String())
+	}
+
+	return o.String()
+}
+
+// Options converts this plan into the options that bind its steps to an enclosing fx.App.
+// These options neither log events nor take measurements.
+func (plan *Plan) Options() fx.Option {
+	return plan.options(loader{})
+}
+
+// isLifecycle tests if this step binds a lifecycle callback.
+func (st Step) isLifecycle() bool {
+	return st.Kind == OnStartSymbol || st.Kind == OnStopSymbol
+}
+
+// pluginError decorates this step's error with its plugin path, symbol, and phase.
+func (st Step) pluginError(path string) error {
+	pe := &PluginError{
+		Path:   path,
+		Symbol: st.Symbol,
+		Phase:  PhaseProvide,
+		Err:    st.Err,
+	}
+
+	switch {
+	case IsMissingSymbolError(st.Err):
+		pe.Phase = PhaseLookup
+
+	case st.isLifecycle():
+		pe.Phase = PhaseLifecycle
+	}
+
+	return pe
+}
+
+// pluginErr returns the combined errors from each step that would shortcircuit
+// application startup, decorated with the given plugin path.
+func (plan *Plan) pluginErr(path string) error {
+	var errs []error
+	for _, st := range plan.Steps {
+		if st.Err != nil && !st.Ignored {
+			errs = append(errs, st.pluginError(path))
+		}
+	}
+
+	return multierr.Combine(errs...)
+}
+
+// report logs the events for each step that could not be bound.
+func (plan *Plan) report(l loader) {
+	for _, st := range plan.Steps {
+		if st.Err != nil {
+			st.report(l)
+		}
+	}
+}
+
+// report logs the event for a step that could not be bound.
+func (st Step) report(l loader) {
+	if IsMissingSymbolError(st.Err) {
+		l.log(&SymbolMissing{
+			Path:    l.path,
+			Name:    st.Symbol,
+			Ignored: st.Ignored,
+		})
+	} else {
+		l.log(&SymbolRejected{
+			Path: l.path,
+			Name: st.Symbol,
+			Err:  st.Err,
+		})
+	}
+}
+
+func (plan *Plan) options(l loader) fx.Option {
+	var (
+		options        []fx.Option
+		hook           fx.Hook
+		bound          LifecycleBound
+		lifecycleError bool
+	)
+
+	for _, st := range plan.Steps {
+		if st.Err != nil {
+			st.report(l)
+			lifecycleError = lifecycleError || (!st.Ignored && st.isLifecycle())
+			continue
+		}
+
+		switch st.Kind {
+		case ProvideSymbol:
+			options = append(options, fx.Provide(l.instrumentFunc(st.Symbol, st.value).Interface()))
+
+		case InvokeSymbol:
+			options = append(options, fx.Invoke(l.instrumentFunc(st.Symbol, st.value).Interface()))
+
+		case TargetSymbol:
+			options = append(options, fx.Provide(
+				fx.Annotated{
+					Name:   st.Name,
+					Group:  st.Group,
+					Target: l.instrumentFunc(st.Symbol, st.value).Interface(),
+				},
+			))
+
+		case OnStartSymbol:
+			hook.OnStart = l.trackHook(StatusStarted, l.instrumentHook(st.Symbol, OnStartDuration, st.callback))
+			bound.OnStart = st.Symbol
+			continue
+
+		case OnStopSymbol:
+			hook.OnStop = l.trackHook(StatusStopped, l.instrumentHook(st.Symbol, OnStopDuration, st.callback))
+			bound.OnStop = st.Symbol
+			continue
+
+		case CheckSymbol:
+			continue
+		}
+
+		l.log(&SymbolBound{
+			Path: l.path,
+			Name: st.Symbol,
+			Kind: st.Kind,
+			Type: st.Type,
+		})
+	}
+
+	if err := plan.pluginErr(l.path); err != nil {
+		// all the problems with a plugin are reported together
+		options = append(options, fx.Error(err))
+	}
+
+	if !lifecycleError && (hook.OnStart != nil || hook.OnStop != nil) {
+		bound.Path = l.path
+		l.log(&bound)
+		options = append(options, fx.Invoke(
+			func(l fx.Lifecycle) {
+				l.Append(hook)
+			},
+		))
+	}
+
+	return fx.Options(options...)
+}
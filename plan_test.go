@@ -0,0 +1,157 @@
+package pluginfx
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type PlanSuite struct {
+	PluginfxSuite
+}
+
+func (suite *PlanSuite) TestSymbolsPlan() {
+	var (
+		sm = NewSymbols(
+			"Constructor", func(*bytes.Buffer) (int, error) { return 0, nil },
+			"Invoke", func(int) {},
+			"Target", func() *bytes.Buffer { return new(bytes.Buffer) },
+			"NotAFunction", 123,
+		)
+
+		plan = Symbols{
+			Names: []interface{}{
+				"Constructor",
+				"Invoke",
+				Annotated{Name: "buffer", Group: "", Target: "Target"},
+				"NotAFunction",
+				"Missing",
+				3.14,
+			},
+			IgnoreMissing: true,
+		}.Plan(sm)
+	)
+
+	suite.Require().Len(plan.Steps, 6)
+
+	st := plan.Steps[0]
+	suite.Equal("Constructor", st.Symbol)
+	suite.Equal(ProvideSymbol, st.Kind)
+	suite.Equal([]reflect.Type{reflect.TypeOf((*bytes.Buffer)(nil))}, st.Params)
+	suite.Equal([]reflect.Type{reflect.TypeOf(0), errType}, st.Results)
+	suite.NoError(st.Err)
+
+	st = plan.Steps[1]
+	suite.Equal(InvokeSymbol, st.Kind)
+	suite.Empty(st.Results)
+
+	st = plan.Steps[2]
+	suite.Equal(TargetSymbol, st.Kind)
+	suite.Equal("buffer", st.Name)
+	suite.Contains(st.String(), `name="buffer"`)
+
+	st = plan.Steps[3]
+	suite.Error(st.Err)
+	suite.False(st.Ignored)
+	suite.Contains(st.String(), "error:")
+
+	st = plan.Steps[4]
+	suite.True(IsMissingSymbolError(st.Err))
+	suite.True(st.Ignored)
+	suite.Nil(st.Type)
+	suite.Contains(st.String(), "ignored")
+
+	st = plan.Steps[5]
+	suite.Equal("3.14", st.Symbol)
+	suite.Error(st.Err)
+
+	suite.Error(plan.Err())
+	suite.Contains(plan.String(), "PROVIDE Constructor")
+}
+
+func (suite *PlanSuite) TestLifecyclePlan() {
+	suite.Run("Valid", func() {
+		plan := Lifecycle{
+			OnStart: "Start",
+			OnStop:  "Stop",
+		}.Plan(NewSymbols(
+			"Start", func(context.Context) {},
+			"Stop", func() error { return nil },
+		))
+
+		suite.Require().Len(plan.Steps, 2)
+		suite.Equal(OnStartSymbol, plan.Steps[0].Kind)
+		suite.Equal(reflect.TypeOf(func(context.Context) {}), plan.Steps[0].Type)
+		suite.Equal(OnStopSymbol, plan.Steps[1].Kind)
+		suite.NoError(plan.Err())
+	})
+
+	suite.Run("Invalid", func() {
+		plan := Lifecycle{
+			OnStart: "Start",
+			OnStop:  "Missing",
+		}.Plan(NewSymbols(
+			"Start", func(int) {},
+		))
+
+		suite.Require().Len(plan.Steps, 2)
+
+		var ile *InvalidLifecycleError
+		suite.ErrorAs(plan.Steps[0].Err, &ile)
+		suite.Equal(reflect.TypeOf(func(int) {}), plan.Steps[0].Type)
+		suite.False(plan.Steps[1].Ignored)
+		suite.Error(plan.Err())
+	})
+
+	suite.Run("Empty", func() {
+		plan := Lifecycle{}.Plan(NewSymbols())
+		suite.Empty(plan.Steps)
+		suite.NoError(plan.Err())
+		suite.Empty(plan.String())
+	})
+}
+
+func (suite *PlanSuite) TestNewPlan() {
+	var (
+		started bool
+		value   float64
+
+		plan = NewPlan(
+			NewSymbols(
+				"New", func() float64 { return expectedNewValue },
+				"Start", func() { started = true },
+			),
+			Symbols{
+				Names: []interface{}{"New"},
+			},
+			Lifecycle{
+				OnStart: "Start",
+			},
+		)
+	)
+
+	suite.Require().Len(plan.Steps, 2)
+	suite.Equal(ProvideSymbol, plan.Steps[0].Kind)
+	suite.Equal(OnStartSymbol, plan.Steps[1].Kind)
+	suite.Equal("PROVIDE New func() float64\nONSTART Start func()", plan.String())
+
+	app := fxtest.New(
+		suite.T(),
+		plan.Options(),
+		fx.Populate(&value),
+	)
+
+	app.RequireStart()
+	suite.True(started)
+	suite.Equal(expectedNewValue, value)
+	app.RequireStop()
+}
+
+func TestPlan(t *testing.T) {
+	suite.Run(t, new(PlanSuite))
+}
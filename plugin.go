@@ -1,9 +1,13 @@
 package pluginfx
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"plugin"
+	"reflect"
+	"strings"
 )
 
 // Plugin defines the behavior of something that can look up
@@ -23,28 +27,101 @@ type Plugin interface {
 type OpenError struct {
 	Path string
 	Err  error
+
+	// Cause is the classification of Err.  Remediation describes how to correct each cause.
+	Cause OpenCause
+
+	// Package is the import path of the package whose version differs between the plugin
+	// and the host.  This field is only set when Cause is CauseGoVersion or CausePackageVersion.
+	Package string
 }
 
 func (oe *OpenError) Unwrap() error {
 	return oe.Err
 }
 
+// Is allows errors.Is(err, fs.ErrNotExist) and errors.Is(err, fs.ErrPermission) to
+// match an OpenError with the corresponding Cause.
+func (oe *OpenError) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return oe.Cause == CauseNotFound
+
+	case fs.ErrPermission:
+		return oe.Cause == CausePermission
+
+	default:
+		return false
+	}
+}
+
 func (oe *OpenError) Error() string {
 	return fmt.Sprintf("Unable to load plugin from path %s: %s", oe.Path, oe.Err)
 }
 
 // Open loads a Plugin from a path.  This is the analog to plugin.Open,
-// and returns a *OpenError instead of a generated error.
+// and returns a *OpenError instead of a generated error.  The OpenError's
+// Cause classifies the failure.
 func Open(path string) (Plugin, error) {
 	p, err := plugin.Open(path)
 	if err != nil {
-		err = &OpenError{
-			Path: path,
-			Err:  err,
+		cause, pkg := diagnose(path, err)
+
+		// avoid returning a nil *plugin.Plugin as a non-nil Plugin
+		return nil, &OpenError{
+			Path:    path,
+			Err:     err,
+			Cause:   cause,
+			Package: pkg,
 		}
 	}
 
-	return p, err
+	return p, nil
+}
+
+// OpenContext is like Open, but gives up when the context is done.  In that case, the
+// returned *OpenError has CauseTimeout and its Err is the context's error, so that
+// errors.Is(err, context.DeadlineExceeded) or errors.Is(err, context.Canceled) holds.
+//
+// Go cannot interrupt a plugin's init functions, so a plugin that is still opening is
+// abandoned rather than stopped.  It may finish loading later, and any later attempt to open
+// the same file waits for it to do so.
+func OpenContext(ctx context.Context, path string) (Plugin, error) {
+	if ctx.Done() == nil {
+		return Open(path)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, &OpenError{
+			Path:  path,
+			Err:   err,
+			Cause: CauseTimeout,
+		}
+	}
+
+	type result struct {
+		p   Plugin
+		err error
+	}
+
+	// buffered, so that an abandoned open does not block forever
+	opened := make(chan result, 1)
+	go func() {
+		p, err := Open(path)
+		opened <- result{p: p, err: err}
+	}()
+
+	select {
+	case r := <-opened:
+		return r.p, r.err
+
+	case <-ctx.Done():
+		return nil, &OpenError{
+			Path:  path,
+			Err:   ctx.Err(),
+			Cause: CauseTimeout,
+		}
+	}
 }
 
 // MissingSymbolError indicates that a symbol was not found.  This error is returned
@@ -74,6 +151,71 @@ func IsMissingSymbolError(err error) bool {
 	return errors.As(err, &mse)
 }
 
+// Phase identifies the step of loading a plugin during which an error occurred.
+type Phase string
+
+const (
+	// PhaseCondition is the phase during which a plugin's Enabled condition is evaluated.
+	PhaseCondition Phase = "condition"
+
+	// PhaseOpen is the phase during which a plugin's path is resolved and the plugin opened.
+	PhaseOpen Phase = "open"
+
+	// PhaseConfigure is the phase during which a plugin's configuration is delivered.
+	PhaseConfigure Phase = "configure"
+
+	// PhaseLookup is the phase during which symbols are looked up in a plugin.
+	PhaseLookup Phase = "lookup"
+
+	// PhaseProvide is the phase during which symbols are validated and bound to
+	// an enclosing fx.App as constructors or invoke functions.
+	PhaseProvide Phase = "provide"
+
+	// PhaseLifecycle is the phase during which symbols are validated and bound to
+	// an enclosing fx.App's lifecycle.
+	PhaseLifecycle Phase = "lifecycle"
+)
+
+// PluginError decorates an error with the plugin and symbol it relates to.  All errors
+// emitted by P, S, Symbols, and Lifecycle into an enclosing fx.App are either a *PluginError
+// or a go.uber.org/multierr combination of them.  The original error is available
+// via errors.As or errors.Is.
+type PluginError struct {
+	// Path is the plugin path.  This field is empty when symbols are loaded directly
+	// via Symbols.Load or Lifecycle.Bind.
+	Path string
+
+	// Symbol is the symbol name, if the error relates to a single symbol.
+	Symbol string
+
+	// Phase is the step of loading during which the error occurred.
+	Phase Phase
+
+	// Err is the original error.
+	Err error
+}
+
+func (pe *PluginError) Unwrap() error {
+	return pe.Err
+}
+
+func (pe *PluginError) Error() string {
+	var o strings.Builder
+	o.WriteString("Plugin")
+	if len(pe.Path) > 0 {
+		o.WriteRune(' ')
+		o.WriteString(pe.Path)
+	}
+
+	if len(pe.Symbol) > 0 {
+		o.WriteString(" symbol ")
+		o.WriteString(pe.Symbol)
+	}
+
+	fmt.Fprintf(&o, " [%s]: %s", pe.Phase, pe.Err)
+	return o.String()
+}
+
 // Lookup invokes s.Lookup and normalizes any error to *MissingSymbolError.
 func Lookup(p Plugin, name string) (interface{}, error) {
 	symbol, err := p.Lookup(name)
@@ -89,3 +231,68 @@ func Lookup(p Plugin, name string) (interface{}, error) {
 
 	return symbol, err
 }
+
+// SymbolTypeError indicates that a symbol was found but did not have the expected type.
+type SymbolTypeError struct {
+	// Name is the symbol name.
+	Name string
+
+	// Expected is the type the caller asked for.
+	Expected reflect.Type
+
+	// Actual is the type of the symbol exported by the plugin.
+	Actual reflect.Type
+}
+
+func (ste *SymbolTypeError) Error() string {
+	return fmt.Sprintf("Symbol %s has type %v, expected %v", ste.Name, ste.Actual, ste.Expected)
+}
+
+// lookupAs looks up a symbol and asserts that it is of type T.
+func lookupAs[T any](p Plugin, name string) (t T, err error) {
+	var symbol interface{}
+	symbol, err = Lookup(p, name)
+	if err != nil {
+		return
+	}
+
+	var ok bool
+	if t, ok = symbol.(T); !ok {
+		err = &SymbolTypeError{
+			Name:     name,
+			Expected: reflect.TypeOf((*T)(nil)).Elem(),
+			Actual:   reflect.TypeOf(symbol),
+		}
+	}
+
+	return
+}
+
+// LookupFunc looks up a function symbol with the exact type F.  A missing symbol results
+// in a *MissingSymbolError, while a symbol of any other type results in a *SymbolTypeError.
+//
+//   newWidget, err := pluginfx.LookupFunc[func() (Widget, error)](p, "NewWidget")
+func LookupFunc[F any](p Plugin, name string) (F, error) {
+	return lookupAs[F](p, name)
+}
+
+// LookupVar looks up a variable symbol of type T.  Since plugins export variables as
+// pointers, the returned value points to the plugin's variable.  A missing symbol results
+// in a *MissingSymbolError, while a symbol of any other type results in a *SymbolTypeError.
+//
+//   value, err := pluginfx.LookupVar[int](p, "Value")
+func LookupVar[T any](p Plugin, name string) (*T, error) {
+	return lookupAs[*T](p, name)
+}
+
+// MustLookup looks up a symbol of type T, panicking on any error.  T must be the exact
+// type of the symbol, i.e. a function type or a pointer type for variables.  This function
+// is intended for initialization code where a missing or mistyped symbol is unrecoverable.
+func MustLookup[T any](p Plugin, name string) T {
+	t, err := lookupAs[T](p, name)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
@@ -2,6 +2,7 @@ package pluginfx
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -89,6 +90,140 @@ func (suite *PluginSuite) TestIsMissingSymbolError() {
 	})
 }
 
+func (suite *PluginSuite) TestPluginError() {
+	suite.Run("Full", func() {
+		var (
+			original = errors.New("expected")
+			pe       = &PluginError{
+				Path:   "test.so",
+				Symbol: "New",
+				Phase:  PhaseProvide,
+				Err:    original,
+			}
+		)
+
+		suite.Equal("Plugin test.so symbol New [provide]: expected", pe.Error())
+		suite.True(errors.Is(pe, original))
+	})
+
+	suite.Run("NoSymbol", func() {
+		pe := &PluginError{
+			Path:  "test.so",
+			Phase: PhaseOpen,
+			Err:   errors.New("expected"),
+		}
+
+		suite.Equal("Plugin test.so [open]: expected", pe.Error())
+	})
+
+	suite.Run("NoPath", func() {
+		pe := &PluginError{
+			Symbol: "Initialize",
+			Phase:  PhaseLifecycle,
+			Err:    errors.New("expected"),
+		}
+
+		suite.Equal("Plugin symbol Initialize [lifecycle]: expected", pe.Error())
+	})
+}
+
+func (suite *PluginSuite) symbolTypeError(expectedName string, expected, actual reflect.Type, err error) {
+	var ste *SymbolTypeError
+	suite.Require().True(errors.As(err, &ste))
+	suite.Equal(expectedName, ste.Name)
+	suite.Equal(expected, ste.Expected)
+	suite.Equal(actual, ste.Actual)
+	suite.NotEmpty(ste.Error())
+}
+
+func (suite *PluginSuite) TestLookupFunc() {
+	p := suite.openSuccess(Open(samplePath))
+
+	suite.Run("Found", func() {
+		f, err := LookupFunc[func() (float64, error)](p, "New")
+		suite.NoError(err)
+		suite.Require().NotNil(f)
+
+		v, err := f()
+		suite.NoError(err)
+		suite.Equal(expectedNewValue, v)
+	})
+
+	suite.Run("Missing", func() {
+		f, err := LookupFunc[func()](p, "Nosuch")
+		suite.Nil(f)
+		suite.missingSymbolError("Nosuch", err)
+	})
+
+	suite.Run("WrongType", func() {
+		f, err := LookupFunc[func() (int, error)](p, "New")
+		suite.Nil(f)
+		suite.symbolTypeError(
+			"New",
+			reflect.TypeOf((func() (int, error))(nil)),
+			reflect.TypeOf((func() (float64, error))(nil)),
+			err,
+		)
+	})
+
+	suite.Run("Variable", func() {
+		f, err := LookupFunc[func()](p, "Value")
+		suite.Nil(f)
+		suite.symbolTypeError("Value", reflect.TypeOf((func())(nil)), reflect.TypeOf((*int)(nil)), err)
+	})
+}
+
+func (suite *PluginSuite) TestLookupVar() {
+	p := suite.openSuccess(Open(samplePath))
+
+	suite.Run("Found", func() {
+		v, err := LookupVar[int](p, "Value")
+		suite.NoError(err)
+		suite.Require().NotNil(v)
+		suite.Equal(12, *v)
+	})
+
+	suite.Run("SymbolMap", func() {
+		v, err := LookupVar[string](NewSymbols("Name", "test"), "Name")
+		suite.NoError(err)
+		suite.Require().NotNil(v)
+		suite.Equal("test", *v)
+	})
+
+	suite.Run("Missing", func() {
+		v, err := LookupVar[int](p, "Nosuch")
+		suite.Nil(v)
+		suite.missingSymbolError("Nosuch", err)
+	})
+
+	suite.Run("WrongType", func() {
+		v, err := LookupVar[string](p, "Value")
+		suite.Nil(v)
+		suite.symbolTypeError("Value", reflect.TypeOf((*string)(nil)), reflect.TypeOf((*int)(nil)), err)
+	})
+}
+
+func (suite *PluginSuite) TestMustLookup() {
+	p := suite.openSuccess(Open(samplePath))
+
+	suite.Run("Found", func() {
+		suite.Equal(12, *MustLookup[*int](p, "Value"))
+		suite.NotNil(MustLookup[func()](p, "Initialize"))
+	})
+
+	suite.Run("Missing", func() {
+		suite.Panics(func() {
+			MustLookup[func()](p, "Nosuch")
+		})
+	})
+
+	suite.Run("WrongType", func() {
+		suite.Panics(func() {
+			MustLookup[int](p, "Value")
+		})
+	})
+}
+
 func TestPlugin(t *testing.T) {
 	suite.Run(t, new(PluginSuite))
 }
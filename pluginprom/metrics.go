@@ -0,0 +1,103 @@
+// Package pluginprom adapts pluginfx.Metrics to Prometheus.
+package pluginprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/pluginfx"
+)
+
+const (
+	// PathLabel is the Prometheus label holding the plugin path.
+	PathLabel = "path"
+
+	// SymbolLabel is the Prometheus label holding the symbol name.
+	SymbolLabel = "symbol"
+)
+
+// help is the Prometheus help text for each counter and histogram reported by pluginfx.
+var help = map[string]string{
+	pluginfx.OpenCounter:      "Number of times a plugin was opened, whether or not the open succeeded.",
+	pluginfx.OpenErrorCounter: "Number of times a plugin could not be opened.",
+	pluginfx.CallCounter:      "Number of calls to plugin constructors and invoke functions.",
+	pluginfx.CallErrorCounter: "Number of calls to plugin constructors and invoke functions that returned an error.",
+	pluginfx.HookCounter:      "Number of plugin OnStart and OnStop callbacks executed.",
+	pluginfx.HookErrorCounter: "Number of plugin OnStart and OnStop callbacks that returned an error.",
+	pluginfx.OpenDuration:     "Time taken to open plugins, including their init functions, in seconds.",
+	pluginfx.CallDuration:     "Time taken by plugin constructors and invoke functions, in seconds.",
+	pluginfx.OnStartDuration:  "Time taken by plugin OnStart callbacks, in seconds.",
+	pluginfx.OnStopDuration:   "Time taken by plugin OnStop callbacks, in seconds.",
+}
+
+// Metrics is a pluginfx.Metrics that reports to Prometheus.  Each counter and histogram
+// named by pluginfx.CounterNames and pluginfx.HistogramNames is registered as a vector
+// with PathLabel and SymbolLabel.  Measurements for any other names are dropped.
+type Metrics struct {
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+var _ pluginfx.Metrics = (*Metrics)(nil)
+
+// New creates a Metrics and registers all of its collectors with the given Registerer.
+// If buckets is empty, prometheus.DefBuckets is used for each histogram.
+func New(r prometheus.Registerer, buckets ...float64) (*Metrics, error) {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	m := &Metrics{
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+
+	for _, name := range pluginfx.CounterNames() {
+		cv := prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: name,
+				Help: help[name],
+			},
+			[]string{PathLabel, SymbolLabel},
+		)
+
+		if err := r.Register(cv); err != nil {
+			return nil, err
+		}
+
+		m.counters[name] = cv
+	}
+
+	for _, name := range pluginfx.HistogramNames() {
+		hv := prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    name,
+				Help:    help[name],
+				Buckets: buckets,
+			},
+			[]string{PathLabel, SymbolLabel},
+		)
+
+		if err := r.Register(hv); err != nil {
+			return nil, err
+		}
+
+		m.histograms[name] = hv
+	}
+
+	return m, nil
+}
+
+// Inc implements pluginfx.Metrics.
+func (m *Metrics) Inc(name string, labels pluginfx.Labels) {
+	if cv, ok := m.counters[name]; ok {
+		cv.WithLabelValues(labels.Path, labels.Symbol).Inc()
+	}
+}
+
+// Observe implements pluginfx.Metrics.  Durations are recorded in seconds.
+func (m *Metrics) Observe(name string, labels pluginfx.Labels, d time.Duration) {
+	if hv, ok := m.histograms[name]; ok {
+		hv.WithLabelValues(labels.Path, labels.Symbol).Observe(d.Seconds())
+	}
+}
@@ -0,0 +1,78 @@
+package pluginprom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/pluginfx"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+}
+
+func (suite *MetricsSuite) TestNew() {
+	suite.Run("DefaultBuckets", func() {
+		r := prometheus.NewPedanticRegistry()
+		m, err := New(r)
+		suite.Require().NoError(err)
+		suite.Require().NotNil(m)
+
+		count, err := testutil.GatherAndCount(r)
+		suite.NoError(err)
+		suite.Zero(count)
+	})
+
+	suite.Run("Help", func() {
+		for _, name := range append(pluginfx.CounterNames(), pluginfx.HistogramNames()...) {
+			suite.NotEmpty(help[name], name)
+		}
+	})
+
+	suite.Run("DuplicateRegistration", func() {
+		r := prometheus.NewPedanticRegistry()
+		_, err := New(r, 0.1, 1.0)
+		suite.Require().NoError(err)
+
+		m, err := New(r)
+		suite.Error(err)
+		suite.Nil(m)
+	})
+}
+
+func (suite *MetricsSuite) TestInc() {
+	r := prometheus.NewPedanticRegistry()
+	m, err := New(r)
+	suite.Require().NoError(err)
+
+	labels := pluginfx.Labels{Path: "test.so", Symbol: "New"}
+	m.Inc(pluginfx.CallCounter, labels)
+	m.Inc(pluginfx.CallCounter, labels)
+	m.Inc("nosuch", labels)
+
+	suite.Equal(
+		2.0,
+		testutil.ToFloat64(m.counters[pluginfx.CallCounter].WithLabelValues("test.so", "New")),
+	)
+}
+
+func (suite *MetricsSuite) TestObserve() {
+	r := prometheus.NewPedanticRegistry()
+	m, err := New(r)
+	suite.Require().NoError(err)
+
+	labels := pluginfx.Labels{Path: "test.so"}
+	m.Observe(pluginfx.OpenDuration, labels, 250*time.Millisecond)
+	m.Observe("nosuch", labels, time.Second)
+
+	count, err := testutil.GatherAndCount(r, pluginfx.OpenDuration)
+	suite.NoError(err)
+	suite.Equal(1, count)
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
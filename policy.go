@@ -0,0 +1,211 @@
+package pluginfx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+)
+
+// ErrInvalidPolicy indicates that an element of Policy.Types was neither a reflect.Type
+// nor a pointer to the allowed type.
+var ErrInvalidPolicy = errors.New("A policy type must be a reflect.Type or a pointer to the allowed type")
+
+// outType is the "cached" reflection type for fx.Out.
+var outType = reflect.TypeOf(fx.Out{})
+
+// Policy restricts the components that plugin constructors may provide to an enclosing
+// fx.App.  This lets a host keep plugins from replacing or colliding with its own components.
+//
+// A component placed into a value group is checked against Groups.  Any other component,
+// named or not, is checked against Types.  Constructors that return fx.Out result structs
+// are checked field by field, using each field's name and group tags.  A flattened group
+// field is reported with the slice's element type, which is what the group receives.
+type Policy struct {
+	// Types are the component types that plugins may provide outside of a value group.
+	// Each element is either a reflect.Type or a nil pointer to the allowed type, e.g.
+	// (*http.Handler)(nil) allows http.Handler components.
+	Types []interface{}
+
+	// Groups are the value groups that plugins may provide components into.
+	Groups []string
+}
+
+// PolicyError indicates that a plugin constructor provides a component that is not allowed
+// by a Policy.
+type PolicyError struct {
+	// Symbol is the name of the constructor symbol.
+	Symbol string
+
+	// Type is the type of the rejected component.
+	Type reflect.Type
+
+	// Name is the component name, if any.
+	Name string
+
+	// Group is the value group of the component, if any.
+	Group string
+}
+
+func (pe *PolicyError) Error() string {
+	switch {
+	case len(pe.Group) > 0:
+		return fmt.Sprintf("Symbol %s may not provide %v to value group %q", pe.Symbol, pe.Type, pe.Group)
+
+	case len(pe.Name) > 0:
+		return fmt.Sprintf("Symbol %s may not provide %v named %q", pe.Symbol, pe.Type, pe.Name)
+
+	default:
+		return fmt.Sprintf("Symbol %s may not provide %v", pe.Symbol, pe.Type)
+	}
+}
+
+// component is a single value a constructor adds to an fx.App.
+type component struct {
+	Type  reflect.Type
+	Name  string
+	Group string
+}
+
+// isResultObject tests if t is a struct that embeds fx.Out.
+func isResultObject(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == outType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resultComponents appends the components described by the fields of an fx.Out struct.
+func resultComponents(c []component, t reflect.Type) []component {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case f.Type == outType:
+			continue
+
+		case isResultObject(f.Type):
+			c = resultComponents(c, f.Type)
+
+		case f.IsExported():
+			// a flattened group receives each element of the slice, not the slice itself
+			t := f.Type
+			group, options, _ := strings.Cut(f.Tag.Get("group"), ",")
+			if t.Kind() == reflect.Slice && hasOption(options, "flatten") {
+				t = t.Elem()
+			}
+
+			c = append(c, component{
+				Type:  t,
+				Name:  f.Tag.Get("name"),
+				Group: group,
+			})
+		}
+	}
+
+	return c
+}
+
+// hasOption tests if a comma-separated list of struct tag options contains option.
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// components returns what a constructor step adds to an fx.App.
+func (st Step) components() (c []component) {
+	for _, rt := range st.Results {
+		switch {
+		case rt == errType:
+			continue
+
+		case st.Kind != TargetSymbol && isResultObject(rt):
+			c = resultComponents(c, rt)
+
+		default:
+			c = append(c, component{
+				Type:  rt,
+				Name:  st.Name,
+				Group: st.Group,
+			})
+		}
+	}
+
+	return
+}
+
+// allowedType tests if t is one of the Types in this policy.
+func (p *Policy) allowedType(t reflect.Type) (bool, error) {
+	for _, v := range p.Types {
+		var allowed reflect.Type
+		switch vt := v.(type) {
+		case reflect.Type:
+			allowed = vt
+
+		default:
+			if allowed = reflect.TypeOf(v); allowed == nil || allowed.Kind() != reflect.Ptr {
+				return false, fmt.Errorf("Invalid policy type %T: %w", v, ErrInvalidPolicy)
+			}
+
+			allowed = allowed.Elem()
+		}
+
+		if allowed == t {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// allowedGroup tests if g is one of the Groups in this policy.
+func (p *Policy) allowedGroup(g string) bool {
+	for _, allowed := range p.Groups {
+		if allowed == g {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check verifies each component provided by a step.  Every violation is reported,
+// combined with go.uber.org/multierr.
+func (p *Policy) check(st Step) (err error) {
+	for _, c := range st.components() {
+		var allowed bool
+		if len(c.Group) > 0 {
+			allowed = p.allowedGroup(c.Group)
+		} else {
+			var typeErr error
+			if allowed, typeErr = p.allowedType(c.Type); typeErr != nil {
+				return typeErr
+			}
+		}
+
+		if !allowed {
+			err = multierr.Append(err, &PolicyError{
+				Symbol: st.Symbol,
+				Type:   c.Type,
+				Name:   c.Name,
+				Group:  c.Group,
+			})
+		}
+	}
+
+	return
+}
@@ -0,0 +1,201 @@
+package pluginfx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
+)
+
+type policyResults struct {
+	fx.Out
+
+	Buffer  *bytes.Buffer
+	Handler http.Handler `name:"admin"`
+	Readers []io.Reader  `group:"readers,flatten"`
+}
+
+type forbiddenFirstResults struct {
+	fx.Out
+
+	Secret string
+	Count  int
+}
+
+type PolicySuite struct {
+	PluginfxSuite
+}
+
+func (suite *PolicySuite) policyErrors(err error) (pes []*PolicyError) {
+	for _, e := range multierr.Errors(err) {
+		var pe *PolicyError
+		suite.Require().True(errors.As(e, &pe), "expected a *PolicyError: %s", e)
+		suite.NotEmpty(pe.Error())
+		pes = append(pes, pe)
+	}
+
+	return
+}
+
+func (suite *PolicySuite) TestAllowed() {
+	var (
+		buffer *bytes.Buffer
+
+		sm = NewSymbols(
+			"Buffer", func() *bytes.Buffer { return new(bytes.Buffer) },
+			"Results", func() policyResults {
+				return policyResults{Buffer: new(bytes.Buffer), Handler: http.NotFoundHandler()}
+			},
+			"Reader", func() io.Reader { return new(bytes.Buffer) },
+			"Invoke", func(b *bytes.Buffer) { buffer = b },
+		)
+
+		plan = Symbols{
+			Names: []interface{}{
+				"Results",
+				Annotated{Group: "readers", Target: "Reader"},
+				"Invoke",
+			},
+			Policy: &Policy{
+				Types: []interface{}{
+					reflect.TypeOf((*bytes.Buffer)(nil)),
+					(*http.Handler)(nil),
+				},
+				Groups: []string{"readers"},
+			},
+		}.Plan(sm)
+	)
+
+	suite.NoError(plan.Err())
+
+	app := fxtest.New(suite.T(), plan.Options())
+	app.RequireStart()
+	app.RequireStop()
+	suite.NotNil(buffer)
+}
+
+func (suite *PolicySuite) TestViolations() {
+	sm := NewSymbols(
+		"Buffer", func() (*bytes.Buffer, error) { return new(bytes.Buffer), nil },
+		"Results", func() policyResults { return policyResults{} },
+		"Reader", func() io.Reader { return new(bytes.Buffer) },
+	)
+
+	suite.Run("Type", func() {
+		plan := Symbols{
+			Names:  []interface{}{"Buffer"},
+			Policy: &Policy{},
+		}.Plan(sm)
+
+		pes := suite.policyErrors(plan.Err())
+		suite.Require().Len(pes, 1)
+		suite.Equal("Buffer", pes[0].Symbol)
+		suite.Equal(reflect.TypeOf((*bytes.Buffer)(nil)), pes[0].Type)
+		suite.Equal("Symbol Buffer may not provide *bytes.Buffer", pes[0].Error())
+	})
+
+	suite.Run("Group", func() {
+		plan := Symbols{
+			Names: []interface{}{Annotated{Group: "writers", Target: "Reader"}},
+			Policy: &Policy{
+				Types:  []interface{}{(*io.Reader)(nil)},
+				Groups: []string{"readers"},
+			},
+		}.Plan(sm)
+
+		pes := suite.policyErrors(plan.Err())
+		suite.Require().Len(pes, 1)
+		suite.Equal("writers", pes[0].Group)
+		suite.Equal(`Symbol Reader may not provide io.Reader to value group "writers"`, pes[0].Error())
+	})
+
+	suite.Run("ResultObject", func() {
+		plan := Symbols{
+			Names: []interface{}{"Results"},
+			Policy: &Policy{
+				Types: []interface{}{(**bytes.Buffer)(nil)},
+			},
+		}.Plan(sm)
+
+		pes := suite.policyErrors(plan.Err())
+		suite.Require().Len(pes, 2)
+		suite.Equal("admin", pes[0].Name)
+		suite.Equal(`Symbol Results may not provide http.Handler named "admin"`, pes[0].Error())
+		suite.Equal("readers", pes[1].Group)
+		suite.Equal(reflect.TypeOf((*io.Reader)(nil)).Elem(), pes[1].Type)
+	})
+
+	suite.Run("AllowedAfterForbidden", func() {
+		// an allowed component must not hide the violations before it
+		sm := NewSymbols(
+			"Pair", func() (string, int) { return "", 0 },
+			"Results", func() forbiddenFirstResults { return forbiddenFirstResults{} },
+		)
+
+		plan := Symbols{
+			Names: []interface{}{"Pair", "Results"},
+			Policy: &Policy{
+				Types: []interface{}{(*int)(nil)},
+			},
+		}.Plan(sm)
+
+		pes := suite.policyErrors(plan.Err())
+		suite.Require().Len(pes, 2)
+		suite.Equal("Pair", pes[0].Symbol)
+		suite.Equal(reflect.TypeOf(""), pes[0].Type)
+		suite.Equal("Results", pes[1].Symbol)
+		suite.Equal(reflect.TypeOf(""), pes[1].Type)
+	})
+
+	suite.Run("InvalidPolicy", func() {
+		plan := Symbols{
+			Names: []interface{}{"Buffer"},
+			Policy: &Policy{
+				Types: []interface{}{"not a type"},
+			},
+		}.Plan(sm)
+
+		suite.ErrorIs(plan.Err(), ErrInvalidPolicy)
+	})
+}
+
+func (suite *PolicySuite) TestP() {
+	var provided bool
+	app := fx.New(
+		P{
+			Anonymous: true,
+			Path:      samplePath,
+			Symbols: Symbols{
+				Names: []interface{}{"New"},
+				Policy: &Policy{
+					Types: []interface{}{(*string)(nil)},
+				},
+			},
+		}.Provide(),
+		fx.Invoke(func(v float64) { provided = true }),
+	)
+
+	err := app.Err()
+	suite.Require().Error(err)
+	suite.False(provided)
+
+	var pe *PolicyError
+	suite.Require().True(errors.As(err, &pe))
+	suite.Equal("New", pe.Symbol)
+	suite.Equal(reflect.TypeOf(float64(0)), pe.Type)
+
+	var plugErr *PluginError
+	suite.Require().True(errors.As(err, &plugErr))
+	suite.Equal(PhaseProvide, plugErr.Phase)
+}
+
+func TestPolicy(t *testing.T) {
+	suite.Run(t, new(PolicySuite))
+}
@@ -0,0 +1,184 @@
+package pluginfx
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"io/fs"
+	"runtime/debug"
+	"strings"
+)
+
+// ModuleMismatch describes a module that a plugin and its host were built with
+// different versions of.
+type ModuleMismatch struct {
+	// Path is the module path.
+	Path string
+
+	// Version is the version of the module the plugin was built with, including
+	// any replacement.
+	Version string
+
+	// HostVersion is the version of the module the host was built with, including
+	// any replacement.
+	HostVersion string
+}
+
+func (mm ModuleMismatch) String() string {
+	return mm.Path + " " + mm.Version + " (host " + mm.HostVersion + ")"
+}
+
+// CompatibilityError indicates that a plugin's build information shows that it was built
+// differently from the host, so it cannot be opened.  Every difference is reported.
+type CompatibilityError struct {
+	// Path is the plugin file.
+	Path string
+
+	// GoVersion is the Go version the plugin was built with.
+	GoVersion string
+
+	// HostGoVersion is the Go version the host was built with.
+	HostGoVersion string
+
+	// Modules are the modules the plugin and the host share, but at different versions,
+	// in the order the plugin lists them.
+	Modules []ModuleMismatch
+}
+
+// GoVersionMismatch tests if the plugin was built with a different Go version than the host.
+func (ce *CompatibilityError) GoVersionMismatch() bool {
+	return ce.GoVersion != ce.HostGoVersion
+}
+
+func (ce *CompatibilityError) Error() string {
+	var o strings.Builder
+	o.WriteString("Plugin ")
+	o.WriteString(ce.Path)
+	o.WriteString(" is incompatible with this host:")
+	if ce.GoVersionMismatch() {
+		o.WriteString("\n\tgo ")
+		o.WriteString(ce.GoVersion)
+		o.WriteString(" (host ")
+		o.WriteString(ce.HostGoVersion)
+		o.WriteRune(')')
+	}
+
+	for _, mm := range ce.Modules {
+		o.WriteString("\n\t")
+		o.WriteString(mm.String())
+	}
+
+	return o.String()
+}
+
+// moduleVersion describes the version of a module, including its replacement.
+func moduleVersion(m *debug.Module) string {
+	v := m.Version
+	if r := m.Replace; r != nil {
+		v += " => " + r.Path
+		if len(r.Version) > 0 {
+			v += "@" + r.Version
+		}
+	}
+
+	return v
+}
+
+// versioned tests if a module version is meaningful across binaries.  Development
+// versions of a main module are not.
+func versioned(m *debug.Module) bool {
+	return len(m.Version) > 0 && m.Version != "(devel)"
+}
+
+// checkCompatibility compares the build information of a plugin with that of its host.
+// The plugin's own main module is not compared, as the runtime only requires that shared
+// packages match.
+func checkCompatibility(path string, host, plugin *debug.BuildInfo) *CompatibilityError {
+	hostModules := make(map[string]*debug.Module, len(host.Deps)+1)
+	if versioned(&host.Main) {
+		hostModules[host.Main.Path] = &host.Main
+	}
+
+	for _, m := range host.Deps {
+		hostModules[m.Path] = m
+	}
+
+	ce := &CompatibilityError{
+		Path:          path,
+		GoVersion:     plugin.GoVersion,
+		HostGoVersion: host.GoVersion,
+	}
+
+	for _, m := range plugin.Deps {
+		hm, ok := hostModules[m.Path]
+		if !ok || !versioned(m) {
+			continue
+		}
+
+		if v, hv := moduleVersion(m), moduleVersion(hm); v != hv {
+			ce.Modules = append(ce.Modules, ModuleMismatch{
+				Path:        m.Path,
+				Version:     v,
+				HostVersion: hv,
+			})
+		}
+	}
+
+	if ce.GoVersionMismatch() || len(ce.Modules) > 0 {
+		return ce
+	}
+
+	return nil
+}
+
+// Preflight checks that the plugin file at path was built compatibly with the running
+// program, without executing any of the plugin's code.  The Go version and module
+// dependencies embedded in the plugin, as read by debug/buildinfo, are compared against
+// runtime/debug.ReadBuildInfo.  If the running program has no build information, this
+// function returns nil.
+//
+// Any error is an *OpenError.  An incompatible plugin results in an OpenError whose Err
+// is a *CompatibilityError listing every difference.  Its Cause is CauseGoVersion if the
+// Go versions differ, CausePackageVersion otherwise, in which case Package is the first
+// mismatched module.
+func Preflight(path string) error {
+	host, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		cause := CauseNotPlugin
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			cause = CauseNotFound
+
+		case errors.Is(err, fs.ErrPermission):
+			cause = CausePermission
+		}
+
+		return &OpenError{
+			Path:  path,
+			Err:   err,
+			Cause: cause,
+		}
+	}
+
+	ce := checkCompatibility(path, host, info)
+	if ce == nil {
+		return nil
+	}
+
+	oe := &OpenError{
+		Path:  path,
+		Err:   ce,
+		Cause: CauseGoVersion,
+	}
+
+	if !ce.GoVersionMismatch() {
+		oe.Cause = CausePackageVersion
+		oe.Package = ce.Modules[0].Path
+	}
+
+	return oe
+}
@@ -0,0 +1,184 @@
+package pluginfx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type PreflightSuite struct {
+	PluginfxSuite
+}
+
+func (suite *PreflightSuite) host() *debug.BuildInfo {
+	return &debug.BuildInfo{
+		GoVersion: "go1.20.4",
+		Main:      debug.Module{Path: "example.com/host", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "example.com/shared", Version: "v1.2.0"},
+			{Path: "example.com/replaced", Version: "v1.0.0", Replace: &debug.Module{Path: "../replaced"}},
+			{Path: "go.uber.org/fx", Version: "v1.18.1"},
+			{Path: "go.uber.org/zap", Version: "v1.21.0"},
+		},
+	}
+}
+
+func (suite *PreflightSuite) TestCheckCompatibility() {
+	suite.Run("Compatible", func() {
+		plugin := &debug.BuildInfo{
+			GoVersion: "go1.20.4",
+			Main:      debug.Module{Path: "example.com/plugin", Version: "v0.1.0"},
+			Deps: []*debug.Module{
+				{Path: "example.com/pluginonly", Version: "v3.0.0"},
+				{Path: "example.com/replaced", Version: "v1.0.0", Replace: &debug.Module{Path: "../replaced"}},
+				{Path: "go.uber.org/fx", Version: "v1.18.1"},
+			},
+		}
+
+		suite.Nil(checkCompatibility("test.so", suite.host(), plugin))
+	})
+
+	suite.Run("GoVersion", func() {
+		plugin := &debug.BuildInfo{
+			GoVersion: "go1.20.3",
+			Main:      debug.Module{Path: "example.com/plugin", Version: "(devel)"},
+		}
+
+		ce := checkCompatibility("test.so", suite.host(), plugin)
+		suite.Require().NotNil(ce)
+		suite.True(ce.GoVersionMismatch())
+		suite.Equal("go1.20.3", ce.GoVersion)
+		suite.Equal("go1.20.4", ce.HostGoVersion)
+		suite.Empty(ce.Modules)
+		suite.Contains(ce.Error(), "go go1.20.3 (host go1.20.4)")
+	})
+
+	suite.Run("Modules", func() {
+		plugin := &debug.BuildInfo{
+			GoVersion: "go1.20.4",
+			Main:      debug.Module{Path: "example.com/plugin", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "example.com/replaced", Version: "v1.0.0"},
+				{Path: "example.com/shared", Version: "v1.3.0"},
+				{Path: "go.uber.org/fx", Version: "v1.18.1"},
+				{Path: "go.uber.org/zap", Version: "v1.21.0", Replace: &debug.Module{Path: "example.com/zapfork", Version: "v1.21.1"}},
+			},
+		}
+
+		ce := checkCompatibility("test.so", suite.host(), plugin)
+		suite.Require().NotNil(ce)
+		suite.False(ce.GoVersionMismatch())
+		suite.Equal(
+			[]ModuleMismatch{
+				{Path: "example.com/replaced", Version: "v1.0.0", HostVersion: "v1.0.0 => ../replaced"},
+				{Path: "example.com/shared", Version: "v1.3.0", HostVersion: "v1.2.0"},
+				{Path: "go.uber.org/zap", Version: "v1.21.0 => example.com/zapfork@v1.21.1", HostVersion: "v1.21.0"},
+			},
+			ce.Modules,
+		)
+
+		msg := ce.Error()
+		suite.NotContains(msg, "go go1.20.4")
+		for _, mm := range ce.Modules {
+			suite.Contains(msg, mm.String())
+		}
+	})
+
+	suite.Run("HostMainModule", func() {
+		host := suite.host()
+		host.Main.Version = "v2.0.0"
+
+		plugin := &debug.BuildInfo{
+			GoVersion: "go1.20.4",
+			Main:      debug.Module{Path: "example.com/plugin", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "example.com/host", Version: "v1.9.0"},
+			},
+		}
+
+		ce := checkCompatibility("test.so", host, plugin)
+		suite.Require().NotNil(ce)
+		suite.Equal([]ModuleMismatch{{Path: "example.com/host", Version: "v1.9.0", HostVersion: "v2.0.0"}}, ce.Modules)
+
+		host.Main.Version = "(devel)"
+		suite.Nil(checkCompatibility("test.so", host, plugin))
+	})
+}
+
+func (suite *PreflightSuite) TestPreflight() {
+	suite.Run("Compatible", func() {
+		suite.NoError(Preflight(samplePath))
+	})
+
+	suite.Run("NotFound", func() {
+		err := Preflight("nosuch.so")
+		oe := suite.openError("nosuch.so", err)
+		suite.Equal(CauseNotFound, oe.Cause)
+	})
+
+	suite.Run("NotPlugin", func() {
+		path := filepath.Join(suite.T().TempDir(), "text.so")
+		suite.Require().NoError(os.WriteFile(path, []byte("this is not a plugin"), 0600))
+
+		err := Preflight(path)
+		oe := suite.openError(path, err)
+		suite.Equal(CauseNotPlugin, oe.Cause)
+	})
+}
+
+func (suite *PreflightSuite) TestP() {
+	suite.Run("Compatible", func() {
+		var (
+			value float64
+			app   = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      samplePath,
+					Preflight: true,
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(67.5, value)
+	})
+
+	suite.Run("Incompatible", func() {
+		var (
+			recorder eventRecorder
+			path     = filepath.Join(suite.T().TempDir(), "text.so")
+		)
+
+		suite.Require().NoError(os.WriteFile(path, []byte("this is not a plugin"), 0600))
+		app := fx.New(
+			S{
+				Paths:     []string{path},
+				Preflight: true,
+				Logger:    &recorder,
+			}.Provide(),
+		)
+
+		var oe *OpenError
+		suite.Require().True(errors.As(app.Err(), &oe))
+		suite.Equal(CauseNotPlugin, oe.Cause)
+
+		suite.Require().Len(recorder.events, 1)
+		suite.IsType((*OpenFailed)(nil), recorder.events[0])
+	})
+}
+
+func TestPreflight(t *testing.T) {
+	suite.Run(t, new(PreflightSuite))
+}
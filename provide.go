@@ -1,8 +1,11 @@
 package pluginfx
 
 import (
+	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"go.uber.org/fx"
 )
@@ -24,9 +27,106 @@ type P struct {
 	Anonymous bool
 
 	// Path is the plugin's path.  This field is required.  Variables are expanded
-	// via os.ExpandEnv.
+	// via Expander.
+	//
+	// Path may also be an http, https, or file URL.  An http or https plugin is downloaded
+	// into CacheDir, named by the SHA-256 of its content, and opened from there.  The server's
+	// ETag and Last-Modified headers are stored alongside the download, so that later starts
+	// make a conditional request and reuse the cached copy if it is not modified.  A file URL
+	// is opened in place.  Fallbacks, if any, are tried in order when a URL is not found.
 	Path string
 
+	// Template enables placeholders in Path and Fallbacks, which are replaced according to
+	// the HostPlatform:  GOOSPlaceholder, GOARCHPlaceholder, and GoVersionPlaceholder.  Placeholders
+	// are replaced after variable expansion.  For example, "/plugins/auth-{goos}-{goarch}-{goversion}.so".
+	Template bool
+
+	// Fallbacks are optional paths tried, in order, when the file at Path does not exist.
+	// Each fallback is expanded and, if Template is set, rendered just as Path is.
+	//
+	// When Template or Fallbacks is set, the first candidate file that exists is opened and a
+	// Selected event is emitted.  If no candidate exists, a *CandidateError that lists each
+	// file tried is returned.
+	Fallbacks []string
+
+	// SearchPath is the optional list of directories that relative paths are resolved against,
+	// in order.  Each directory is expanded via Expander.  The SearchPathEnv environment variable,
+	// if defined, overrides this field.  With no search path, relative paths are resolved against
+	// the current working directory.  In all cases, the plugin is opened via an absolute path.
+	//
+	// When more than one location is searched, the first file that exists is opened as with
+	// Fallbacks, and a *CandidateError lists every location tried if there is no such file.
+	SearchPath []string
+
+	// FS is the optional file system that the plugin file is read from, such as an embed.FS.
+	// When set, Path and Fallbacks are names within FS, as described by fs.ValidPath, and
+	// SearchPath is ignored.  The chosen file is copied into CacheDir, named by the SHA-256
+	// of its contents, and opened from there.  An existing copy is reused.
+	FS fs.FS
+
+	// CacheDir is the directory that files from FS are copied into and that plugins
+	// are downloaded and built into.  If unset, DefaultCacheDir is used.
+	CacheDir string
+
+	// CleanupOnStop controls whether the copy of a plugin file from FS is removed when
+	// the enclosing fx.App stops.  This field is ignored if FS is nil.
+	CleanupOnStop bool
+
+	// Digest is the optional expected SHA-256 of the plugin file, hex-encoded and optionally
+	// prefixed with "sha256:".  This field only applies when Path is a URL.  A plugin whose
+	// content does not match results in a *DigestError.
+	Digest string
+
+	// HTTPClient is the optional client used to download plugins when Path is a URL.
+	// If unset, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Build indicates that Path and Fallbacks are Go package directories rather than plugin
+	// files.  The chosen directory is built with go build -buildmode=plugin into CacheDir,
+	// using the host's Go version and build flags, and a Built event is emitted.  Artifacts are
+	// keyed by the package's source, the toolchain, and the module graph, so an unchanged package
+	// is only built once.  A package that fails to build results in a *BuildError that contains
+	// the go command's output.  Neither FS nor a URL can be built, and setting this field along
+	// with either results in ErrBuildSource.
+	Build bool
+
+	// OpenTimeout is the optional limit on how long opening the plugin may take, which
+	// includes running its init functions.  If the plugin does not open in time, it is abandoned
+	// and the error is an *OpenError with CauseTimeout.  See OpenContext.  If unset, opening
+	// the plugin may take any amount of time.
+	OpenTimeout time.Duration
+
+	// Lazy defers opening the plugin until something needs it:  the first call to one of
+	// its constructors, invoke functions, or lifecycle callbacks, or dig's first request for the
+	// Plugin component itself.  Until then, the plugin's Record has StatusPending.
+	//
+	// Because symbols are bound before the plugin is opened, every symbol in Symbols.Names
+	// and Lifecycle, and every other entry in Symbols.Contracts, must have a function contract
+	// whose last result is an error.  Otherwise, startup fails with a PhaseProvide *PluginError
+	// wrapping ErrLazyContract, even if IgnoreMissing is set.  Any failure to open or configure the
+	// plugin, or a symbol that does not match its contract, is returned from the function that
+	// was called.  Path is still resolved, fetched, or built eagerly.  This field is ignored if
+	// Optional is set, as an optional plugin must be opened to know whether it is present.
+	Lazy bool
+
+	// Preflight enables a check of the plugin file's build information before it is opened,
+	// so that a plugin built with a different Go version or different module versions than
+	// the host is rejected without running any of its code.  See the Preflight function.
+	Preflight bool
+
+	// Enabled is the optional Condition that decides whether this plugin is loaded at all.
+	// It is evaluated before Path is expanded.  A disabled plugin is not opened, but it
+	// is still recorded:  a Disabled event is emitted and its Record has StatusDisabled.
+	// If Optional is also set and Anonymous is not, an OptionalPlugin that is not Present
+	// is provided.  If unset, the plugin is always enabled.
+	Enabled Condition
+
+	// Expander is the optional strategy for expanding variables in Path.  If unset,
+	// a default ShellExpander is used, which behaves like os.ExpandEnv with support
+	// for defaults and required variables.  Any expansion error shortcircuits application
+	// startup with a *PluginError that contains the original, unexpanded path.
+	Expander Expander
+
 	// Symbols describes the optional set of functions exported by the plugin to be
 	// bound to the enclosing fx.App.  Both provide and invoke functions can be defined
 	// using this field.
@@ -35,6 +135,69 @@ type P struct {
 	// Lifecycle is the optional binding from a plugin's symbols to the enclosing
 	// application.
 	Lifecycle Lifecycle
+
+	// Config is the optional configuration for this plugin.  If set, the plugin must export
+	// a function symbol named by ConfigureSymbol with one of the following signatures,
+	// where T is any type that can be decoded from this configuration:
+	//
+	//   - func(T)
+	//   - func(T) error
+	//
+	// The configuration is decoded into a new T and passed to that function immediately
+	// after the plugin is opened, before any of its other symbols are bound.  This field may be:
+	//
+	//   - a Decoder, such as those returned by JSON or YAML
+	//   - a []byte or string, which is decoded as JSON
+	//   - any other value, such as a map[string]interface{}, which is converted into T
+	//     by way of encoding/json
+	Config interface{}
+
+	// ConfigureSymbol is the name of the function that receives Config.  If unset,
+	// DefaultConfigureSymbol is used.  This field is ignored if Config is nil.
+	ConfigureSymbol string
+
+	// Logger is the optional sink for events that occur while this plugin is
+	// loaded and bound.  If unset, NopLogger is used.
+	Logger Logger
+
+	// Metrics is the optional sink for measurements of this plugin's open time
+	// along with each call to its constructors, invoke functions, and lifecycle callbacks.
+	//
+	// Each constructor and invoke function is measured by wrapping it with reflect.MakeFunc.
+	// Since fx and dig name functions by their code, fx's own events and dig's errors name every
+	// measured function reflect.makeFuncStub rather than its plugin symbol.  The SymbolBound and
+	// SymbolRejected events still name each symbol and its plugin, and FxeventLogger delivers
+	// them to the same fxevent.Logger as the enclosing fx.App's events.
+	Metrics Metrics
+
+	// Optional controls what happens when this plugin cannot be opened or any of its
+	// symbols cannot be bound.  If this field is false, application startup is shortcircuited
+	// with an error.  If this field is true, the plugin is skipped:  none of its symbols
+	// are bound, a Skipped event is emitted, and OnError is invoked.
+	//
+	// When this field is true and Anonymous is false, an OptionalPlugin component is
+	// provided in place of the Plugin, using the Name and Group fields as usual.
+	Optional bool
+
+	// OnError is the optional callback invoked when an Optional plugin is skipped.  The
+	// error passed to this callback is a *PluginError or a multierr combination of them.
+	// This field is ignored if Optional is false.
+	OnError func(error)
+
+	// Tracker is the optional record of plugin files already loaded by this fx.App, which
+	// detects the same file loaded again through another path, a symbolic link, or a hard link.
+	// Its Policy decides whether a duplicate is skipped, is an error, or is provided as an alias.
+	// The same Tracker must be shared by every P and S that should be deduplicated together.
+	// If unset, plugins are not deduplicated, and fx typically fails with duplicate providers.
+	Tracker *Tracker
+
+	// deadline is set by S so that every plugin in a set shares one deadline for opening.
+	// It takes precedence over OpenTimeout.
+	deadline time.Time
+
+	// seq is set by S so that each plugin's place in the Registry is decided when it is
+	// declared, rather than when it is loaded.  If unset, the place is decided by newLoader.
+	seq uint64
 }
 
 // Provide builds the appropriate options to integrate this plugin into an
@@ -57,39 +220,282 @@ type P struct {
 //     }.Provide()
 //   )
 func (p P) Provide() fx.Option {
-	var options []fx.Option
-	plugin, err := Open(os.ExpandEnv(p.Path))
+	if enabled, err := checkEnabled(p.Enabled); err != nil {
+		return p.fail(
+			p.newLoader(p.Path, p.Path),
+			&PluginError{
+				Path:  p.Path,
+				Phase: PhaseCondition,
+				Err:   err,
+			},
+		)
+	} else if !enabled {
+		return p.disable(p.newLoader(p.Path, p.Path), p.Enabled)
+	}
 
-	if err == nil {
-		options = append(options, p.Symbols.Load(plugin))
-		options = append(options, p.Lifecycle.Bind(plugin))
+	path, name, events, cleanup, err := p.resolve()
+	if err != nil {
+		return p.fail(
+			p.newLoader(p.Path, p.Path),
+			&PluginError{
+				Path:  p.Path,
+				Phase: PhaseOpen,
+				Err:   err,
+			},
+		)
+	}
+
+	l := p.newLoader(p.Path, path)
+	for _, e := range events {
+		l.log(e)
+	}
+
+	return fx.Options(p.provide(l, name), cleanup)
+}
+
+// resolve determines the local file this plugin is opened from, which may involve searching,
+// rendering templates, copying from an fs.FS, downloading, or building.  The returned name is
+// the one chosen before any of that:  a name within FS, a URL, or a package directory.  The
+// returned events describe how the file was chosen, and the returned option cleans up any
+// temporary copy.
+func (p P) resolve() (path, name string, events []Event, cleanup fx.Option, err error) {
+	cleanup = fx.Options()
+	candidates, err := p.candidates()
+	if err != nil {
+		return
+	}
+
+	if p.Build && (p.FS != nil || anyURL(candidates)) {
+		err = ErrBuildSource
+		return
+	}
+
+	var (
+		selected  = candidates[0]
+		selecting = p.Template || len(p.Fallbacks) > 0 || len(candidates) > 1
+		src       = newFSSource(p.FS, p.CacheDir, p.CleanupOnStop)
+	)
+
+	switch {
+	case src == nil && isURL(selected):
+		var fetched *Fetched
+		fetched, err = newFetcher(p.HTTPClient, p.CacheDir).fetchFirst(p.Path, candidates, p.Digest)
+		if err == nil {
+			selected, path = fetched.URL, fetched.Path
+			events = append(events, fetched)
+		}
+
+	case src != nil:
+		if selecting {
+			selected, err = selectCandidate(p.Path, candidates, src.stat)
+		}
+
+		if err == nil {
+			path, err = src.materialize(selected)
+			cleanup = src.cleanupOption(path)
+		}
+
+	case selecting:
+		selected, err = selectCandidate(p.Path, candidates, os.Stat)
+		path = selected
+
+	default:
+		path = selected
+	}
+
+	if err == nil && p.Build {
+		var built *Built
+		if built, err = newBuilder(p.CacheDir).build(path); err == nil {
+			path = built.Path
+			events = append(events, built)
+		}
+	}
+
+	if err == nil && selecting {
+		events = append([]Event{&Selected{Path: selected, Candidates: candidates}}, events...)
+	}
+
+	name = selected
+	return
+}
+
+// anyURL tests if any of the given candidates is a URL.
+func anyURL(candidates []string) bool {
+	for _, c := range candidates {
+		if isURL(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// candidates expands, renders, and searches for Path and Fallbacks, in order.
+func (p P) candidates() (candidates []string, err error) {
+	dirs, err := searchPath(p.Expander, p.SearchPath)
+	if err != nil {
+		return
+	}
+
+	for _, path := range append([]string{p.Path}, p.Fallbacks...) {
+		if path, err = expand(p.Expander, path); err != nil {
+			return
+		}
+
+		rendered := []string{path}
+		if p.Template {
+			rendered = HostPlatform().Render(path)
+		}
+
+		if p.FS != nil {
+			candidates = append(candidates, rendered...)
+			continue
+		}
+
+		for _, r := range rendered {
+			candidates = append(candidates, search(r, dirs)...)
+		}
+	}
+
+	return
+}
+
+// expand applies an Expander to a path, using a ShellExpander if e is nil.
+func expand(e Expander, path string) (string, error) {
+	if e == nil {
+		e = ShellExpander{}
+	}
+
+	return e.Expand(path)
+}
+
+// newLoader creates the loader for this plugin.  The configured path is the one reported
+// in this plugin's Record, while path is the one actually opened.
+func (p P) newLoader(configured, path string) loader {
+	seq := p.seq
+	if seq == 0 {
+		seq = nextSequence()
+	}
+
+	return loader{
+		path:      path,
+		logger:    p.Logger,
+		metrics:   p.Metrics,
+		preflight: p.Preflight,
+		timeout:   p.OpenTimeout,
+		deadline:  p.deadline,
+		entry: &registryEntry{
+			seq: seq,
+			record: Record{
+				Path:         configured,
+				ExpandedPath: path,
+				Name:         p.Name,
+				Group:        p.Group,
+				LoadTime:     time.Now(),
+			},
+		},
+	}
+}
+
+// fail handles an error that prevents this plugin from being opened at all.
+func (p P) fail(l loader, err error) fx.Option {
+	if p.Optional {
+		return fx.Options(l.provideEntry(), p.provideOptional(l, nil, err))
+	}
+
+	return fx.Error(err)
+}
+
+// disable records that this plugin was not loaded because of the given condition.
+func (p P) disable(l loader, c Condition) fx.Option {
+	l.log(&Disabled{Path: l.path, Condition: describe(c)})
+	if p.Optional {
+		return fx.Options(l.provideEntry(), p.provideOptional(l, nil, nil))
+	}
+
+	return l.provideEntry()
+}
+
+// provide does the work of Provide once the path to open is known.  The file is tracked
+// as name, which is how a duplicate of it is reported.
+func (p P) provide(l loader, name string) fx.Option {
+	return p.provideTracked(l, p.Tracker.track(l.path, name))
+}
+
+// provideTracked does the work of provide once the path has been checked against the Tracker.
+// If d is not nil, the path is a duplicate of a plugin file that was already loaded.
+func (p P) provideTracked(l loader, d *duplicate) fx.Option {
+	if d != nil {
+		return p.provideDuplicate(l, d)
+	}
+
+	if p.Lazy && !p.Optional {
+		return p.provideLazy(l)
+	}
+
+	var (
+		path    = l.path
+		options = []fx.Option{l.provideEntry()}
+	)
+
+	plugin, err := l.open()
+	if err != nil {
+		err = &PluginError{
+			Path:  path,
+			Phase: PhaseOpen,
+			Err:   err,
+		}
+	} else if cerr := p.configure(l, plugin); cerr != nil {
+		if p.Optional {
+			plugin, err = nil, cerr
+		} else {
+			options = append(options, fx.Error(cerr))
+		}
+	} else {
+		plan := NewPlan(plugin, p.Symbols, p.Lifecycle)
+		if planErr := plan.pluginErr(path); p.Optional && planErr != nil {
+			// an optional plugin is either bound completely or not at all
+			plan.report(l)
+			plugin, err = nil, planErr
+		} else {
+			options = append(options, plan.options(l))
+		}
+	}
+
+	if p.Optional {
+		return fx.Options(append(options, p.provideOptional(l, plugin, err))...)
 	}
 
 	// emit the plugin as a component if desired, even when there's an error.
 	// this lets the fx.App produce useful error messages.
+	return fx.Options(append(options, p.component(plugin, err))...)
+}
+
+// component provides the plugin itself, according to Name, Group, and Anonymous.  If the
+// plugin is anonymous, err still shortcircuits startup.
+func (p P) component(plugin Plugin, err error) fx.Option {
 	switch {
 	case !p.Anonymous && (len(p.Name) > 0 || len(p.Group) > 0):
-		options = append(options, fx.Provide(
+		return fx.Provide(
 			fx.Annotated{
 				Name:   p.Name,
 				Group:  p.Group,
 				Target: func() (Plugin, error) { return plugin, err },
 			},
-		))
+		)
 
 	case !p.Anonymous:
-		options = append(options, fx.Provide(
+		return fx.Provide(
 			func() (Plugin, error) { return plugin, err },
-		))
+		)
 
 	case err != nil:
 		// need to short-circuit startup, even though no component is created
-		options = append(options,
-			fx.Error(err),
-		)
-	}
+		return fx.Error(err)
 
-	return fx.Options(options...)
+	default:
+		return fx.Options()
+	}
 }
 
 // S describes how to load multiple plugins as a bundle and integrate each of them
@@ -101,42 +507,308 @@ type S struct {
 
 	// Paths are the plugin paths to load.  Each of these paths may be a filesystem glob,
 	// in which case all matching files are loaded as plugins.  Variable expansion is also
-	// done on each element via os.ExpandEnv.
+	// done on each element via Expander.
 	Paths []string
 
+	// Enabled is the optional Condition that decides whether any of the plugins in this set
+	// are loaded.  When disabled, each element of Paths is recorded as a disabled plugin
+	// without being expanded or globbed.
+	Enabled Condition
+
+	// Expander is the optional strategy for expanding variables in each element of Paths.
+	// If unset, a default ShellExpander is used.
+	Expander Expander
+
+	// Template enables placeholders in each element of Paths, as with P.Template.  Each
+	// rendered candidate is globbed in order, and the first one with any matches is used.
+	Template bool
+
+	// SearchPath is the optional list of directories that relative elements of Paths are
+	// resolved against, as with P.SearchPath.  The pattern is globbed within each directory
+	// in order, and the first directory with any matches is used.  As with any glob, a pattern
+	// that matches nothing in any directory loads no plugins.
+	SearchPath []string
+
+	// FS is the optional file system that plugin files are read from, as with P.FS.  Each
+	// element of Paths is matched with fs.Glob, and SearchPath is ignored.
+	FS fs.FS
+
+	// CacheDir is the directory that files from FS are copied into and that plugins are
+	// downloaded into, as with P.CacheDir.
+	CacheDir string
+
+	// CleanupOnStop controls whether copies of plugin files from FS are removed when
+	// the enclosing fx.App stops.
+	CleanupOnStop bool
+
+	// Digests are the optional expected SHA-256 digests of plugins, keyed by elements of
+	// Paths.  An element of Paths may be an http, https, or file URL, as with P.Path, in which
+	// case it refers to a single plugin and is not globbed.
+	Digests map[string]string
+
+	// HTTPClient is the optional client used to download plugins.  If unset, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+
+	// Preflight enables a check of each plugin file's build information before it is opened.
+	// See P.Preflight.
+	Preflight bool
+
 	// Symbols are the symbols to be loaded from each loaded plugin.
 	Symbols Symbols
 
 	// Lifecycle describes the symbols from each loaded plugin to be bound to the
 	// enclosing application.
 	Lifecycle Lifecycle
+
+	// Logger is the optional sink for events that occur while each plugin is
+	// loaded and bound.  If unset, NopLogger is used.
+	Logger Logger
+
+	// Metrics is the optional sink for measurements taken for each plugin.  See P.Metrics.
+	Metrics Metrics
+
+	// Optional indicates that each plugin in this set is skipped rather than shortcircuiting
+	// application startup if it cannot be opened or bound.  When this field is true and
+	// Group is set, the value group contains OptionalPlugin components rather than Plugin components.
+	Optional bool
+
+	// OnError is the optional callback invoked for each Optional plugin that is skipped.
+	OnError func(error)
+
+	// OpenTimeout is the optional limit on how long opening this whole set of plugins may take,
+	// starting when Provide is called.  Every plugin shares the same deadline, so a plugin that
+	// blocks uses up the time of the plugins after it.  See P.OpenTimeout.
+	OpenTimeout time.Duration
+
+	// Tracker is the optional record of plugin files already loaded, shared with any P or S in
+	// the same fx.App.  See P.Tracker.  Each plugin is tracked once its file is local, but always
+	// in the order of Paths and of the matches for each path, even when files are fetched or
+	// copied concurrently.  So the first of any duplicates is always the one that is loaded.
+	Tracker *Tracker
+
+	// Concurrency is the maximum number of plugins in this set that are fetched, built, opened,
+	// and bound at the same time.  Values less than 2 load each plugin in turn.  Regardless of
+	// this field, the options for each plugin are returned in the order of Paths and of the
+	// matches for each path, so the order of provide and invoke functions is stable.  Every
+	// plugin is attempted, and startup fails with all of their errors.
+	//
+	// Logger and OnError are never called concurrently, but events for different plugins
+	// may interleave.  Metrics must be safe for concurrent use, as always.
+	Concurrency int
 }
 
 // Provide opens a list of plugins described in the Paths field.  These plugins are optionally
 // put into a value group if the Group field is set.  Each plugin is then examined for symbols
 // to provide to the enclosing fx.App in a manner similar to Plugin.Provide.
+//
+// Paths are expanded and globbed in turn, after which each plugin is fetched, built, opened,
+// and bound by up to Concurrency goroutines.
 func (s S) Provide() fx.Option {
-	var options []fx.Option
+	var (
+		tasks []func() fx.Option
+		last  turn
+		p     = s.p()
+		src   = newFSSource(s.FS, s.CacheDir, s.CleanupOnStop)
+		glob  = filepath.Glob
+	)
+
+	if src != nil {
+		glob = src.glob
+	}
+
+	if s.Concurrency > 1 {
+		p = p.serialize()
+	}
+
+	if s.OpenTimeout > 0 {
+		p.deadline = time.Now().Add(s.OpenTimeout)
+	}
+
+	enabled, condErr := checkEnabled(s.Enabled)
 	for _, path := range s.Paths {
-		matches, err := filepath.Glob(os.ExpandEnv(path))
+		path := path
+		switch {
+		case condErr != nil:
+			tasks = append(tasks, done(p.fail(
+				p.newLoader(path, path),
+				&PluginError{
+					Path:  path,
+					Phase: PhaseCondition,
+					Err:   condErr,
+				},
+			)))
+
+			continue
+
+		case !enabled:
+			tasks = append(tasks, done(p.disable(p.newLoader(path, path), s.Enabled)))
+			continue
+		}
+
+		var (
+			pattern         string
+			matches         []string
+			candidates, err = s.candidates(path)
+		)
+
+		if err == nil && src == nil && isURL(candidates[0]) {
+			var (
+				f = newFetcher(s.HTTPClient, s.CacheDir)
+				t = last.next()
+				p = p.sequenced()
+			)
+
+			last = t
+			tasks = append(tasks, func() fx.Option {
+				defer t.end()
+				return p.provideURL(f, path, candidates, s.Digests[path], t)
+			})
+
+			continue
+		}
+
+		for i := 0; err == nil && len(matches) == 0 && i < len(candidates); i++ {
+			pattern = candidates[i]
+			matches, err = glob(pattern)
+		}
+
 		if err != nil {
-			options = append(options, fx.Error(err))
+			tasks = append(tasks, done(p.fail(
+				p.newLoader(path, path),
+				&PluginError{
+					Path:  path,
+					Phase: PhaseOpen,
+					Err:   err,
+				},
+			)))
+
 			continue
 		}
 
 		for _, match := range matches {
-			options = append(options,
-				P{
-					Group:     s.Group,
-					Anonymous: len(s.Group) == 0,
-					Path:      match,
-
-					Symbols:   s.Symbols,
-					Lifecycle: s.Lifecycle,
-				}.Provide(),
+			var (
+				match = match
+				t     = last.next()
+				p     = p.sequenced()
 			)
+
+			last = t
+			if src != nil {
+				tasks = append(tasks, func() fx.Option {
+					defer t.end()
+					return p.provideFS(src, path, match, t)
+				})
+
+				continue
+			}
+
+			selecting := s.Template || len(candidates) > 1
+			tasks = append(tasks, func() fx.Option {
+				l := p.newLoader(path, match)
+				if selecting {
+					l.log(&Selected{Path: pattern, Candidates: candidates})
+				}
+
+				return p.provideTracked(l, t.track(p.Tracker, match, match))
+			})
 		}
 	}
 
-	return fx.Options(options...)
+	return fx.Options(parallel(s.Concurrency, tasks)...)
+}
+
+// sequenced returns a copy of this P with its place in the Registry decided now.
+func (p P) sequenced() P {
+	p.seq = nextSequence()
+	return p
+}
+
+// provideURL fetches the first of several candidate URLs and opens it.  The downloaded file is
+// tracked, as its URL, during the given turn.
+func (p P) provideURL(f fetcher, configured string, candidates []string, digest string, t turn) fx.Option {
+	fetched, err := f.fetchFirst(configured, candidates, digest)
+	if err != nil {
+		return p.fail(
+			p.newLoader(configured, configured),
+			&PluginError{
+				Path:  configured,
+				Phase: PhaseOpen,
+				Err:   err,
+			},
+		)
+	}
+
+	l := p.newLoader(configured, fetched.Path)
+	if len(candidates) > 1 {
+		l.log(&Selected{Path: fetched.URL, Candidates: candidates})
+	}
+
+	l.log(fetched)
+	return p.provideTracked(l, t.track(p.Tracker, fetched.Path, fetched.URL))
+}
+
+// provideFS opens a single file matched within an fs.FS.  The copy of the file is tracked,
+// as its name within the FS, during the given turn.
+func (p P) provideFS(src *fsSource, configured, match string, t turn) fx.Option {
+	path, err := src.materialize(match)
+	if err != nil {
+		return p.fail(
+			p.newLoader(configured, match),
+			&PluginError{
+				Path:  match,
+				Phase: PhaseOpen,
+				Err:   err,
+			},
+		)
+	}
+
+	return fx.Options(
+		p.provideTracked(p.newLoader(configured, path), t.track(p.Tracker, path, match)),
+		src.cleanupOption(path),
+	)
+}
+
+// candidates expands, renders, and searches for one element of Paths.
+func (s S) candidates(path string) (candidates []string, err error) {
+	dirs, err := searchPath(s.Expander, s.SearchPath)
+	if err == nil {
+		path, err = expand(s.Expander, path)
+	}
+
+	if err != nil {
+		return
+	}
+
+	rendered := []string{path}
+	if s.Template {
+		rendered = HostPlatform().Render(path)
+	}
+
+	if s.FS != nil {
+		return rendered, nil
+	}
+
+	for _, r := range rendered {
+		candidates = append(candidates, search(r, dirs)...)
+	}
+
+	return
+}
+
+// p returns the P that describes how each plugin in this set is loaded.
+func (s S) p() P {
+	return P{
+		Group:     s.Group,
+		Anonymous: len(s.Group) == 0,
+
+		Symbols:   s.Symbols,
+		Lifecycle: s.Lifecycle,
+		Preflight: s.Preflight,
+		Logger:    s.Logger,
+		Metrics:   s.Metrics,
+		Optional:  s.Optional,
+		OnError:   s.OnError,
+		Tracker:   s.Tracker,
+	}
 }
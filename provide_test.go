@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
 )
 
 const expectedNewValue float64 = 67.5
@@ -203,6 +204,62 @@ func (suite *ProvideSuite) testPAnonymousError() {
 	var oe *OpenError
 	suite.Require().True(errors.As(err, &oe))
 	suite.NotEmpty(oe.Error())
+
+	var pe *PluginError
+	suite.Require().True(errors.As(err, &pe))
+	suite.Equal("/no/such/plugin.123", pe.Path)
+	suite.Equal(PhaseOpen, pe.Phase)
+}
+
+func (suite *ProvideSuite) testPErrors() {
+	app := fx.New(
+		P{
+			Anonymous: true,
+			Path:      samplePath,
+			Symbols: Symbols{
+				Names: []interface{}{
+					"Missing",
+					"Value",
+					Annotated{Name: "invalid", Target: "Initialize"},
+				},
+			},
+			Lifecycle: Lifecycle{
+				OnStart: "New",
+			},
+		}.Provide(),
+	)
+
+	err := app.Err()
+	suite.Require().Error(err)
+
+	errs := multierr.Errors(err)
+	suite.Require().Len(errs, 4)
+
+	expected := []struct {
+		symbol string
+		phase  Phase
+	}{
+		{"Missing", PhaseLookup},
+		{"Value", PhaseProvide},
+		{"Initialize", PhaseProvide},
+		{"New", PhaseLifecycle},
+	}
+
+	for i, e := range expected {
+		var pe *PluginError
+		suite.Require().True(errors.As(errs[i], &pe))
+		suite.Equal(sampleOpenPath, pe.Path)
+		suite.Equal(e.symbol, pe.Symbol)
+		suite.Equal(e.phase, pe.Phase)
+	}
+
+	suite.True(IsMissingSymbolError(errs[0]))
+
+	var ite *InvalidTargetError
+	suite.True(errors.As(errs[2], &ite))
+
+	var ile *InvalidLifecycleError
+	suite.True(errors.As(errs[3], &ile))
 }
 
 func (suite *ProvideSuite) TestP() {
@@ -212,6 +269,7 @@ func (suite *ProvideSuite) TestP() {
 	suite.Run("Named", suite.testPNamed)
 	suite.Run("Group", suite.testPGroup)
 	suite.Run("AnonymousError", suite.testPAnonymousError)
+	suite.Run("Errors", suite.testPErrors)
 }
 
 func (suite *ProvideSuite) testSAnonymous() {
@@ -0,0 +1,372 @@
+package pluginfx
+
+import (
+	"context"
+	"debug/buildinfo"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+)
+
+// registryGroup is the value group each plugin's registry entry is placed into.
+const registryGroup = "pluginfx.registry"
+
+// Status describes where a plugin is in its lifecycle.
+type Status int
+
+const (
+	// StatusLoaded indicates that a plugin was opened and its symbols bound.
+	StatusLoaded Status = iota
+
+	// StatusFailed indicates that a plugin could not be opened, or that
+	// one or more of its symbols could not be bound or executed.
+	StatusFailed
+
+	// StatusStarted indicates that a plugin's OnStart callback completed successfully.
+	StatusStarted
+
+	// StatusStopped indicates that a plugin's OnStop callback completed successfully.
+	StatusStopped
+
+	// StatusSkipped indicates that an optional plugin could not be opened or bound,
+	// and was left out of the enclosing fx.App.
+	StatusSkipped
+
+	// StatusDisabled indicates that a plugin was not loaded because its Enabled
+	// condition did not hold.
+	StatusDisabled
+
+	// StatusPending indicates that a lazy plugin's symbols were bound, but nothing
+	// has needed the plugin yet, so it has not been opened.
+	StatusPending
+
+	// StatusDuplicate indicates that a plugin path resolved to a plugin file that was
+	// already loaded through another path, so it was not opened again.
+	StatusDuplicate
+)
+
+// String returns a human-readable label for this status.
+func (s Status) String() string {
+	switch s {
+	case StatusLoaded:
+		return "loaded"
+
+	case StatusFailed:
+		return "failed"
+
+	case StatusStarted:
+		return "started"
+
+	case StatusStopped:
+		return "stopped"
+
+	case StatusSkipped:
+		return "skipped"
+
+	case StatusDisabled:
+		return "disabled"
+
+	case StatusPending:
+		return "pending"
+
+	case StatusDuplicate:
+		return "duplicate"
+
+	default:
+		return "unknown"
+	}
+}
+
+// BoundSymbol describes a plugin symbol that was bound to the enclosing fx.App.
+type BoundSymbol struct {
+	// Name is the symbol name.
+	Name string
+
+	// Kind describes how the symbol was bound.
+	Kind SymbolKind
+
+	// Type is the function type of the symbol.
+	Type reflect.Type
+}
+
+// Record is a snapshot of what is known about a single plugin.
+type Record struct {
+	// Path is the plugin path as configured, prior to any expansion.
+	Path string
+
+	// ExpandedPath is the path the plugin was actually opened from.
+	ExpandedPath string
+
+	// Name is the component name of the plugin, if any.
+	Name string
+
+	// Group is the value group of the plugin, if any.
+	Group string
+
+	// Symbols are the plugin's symbols that were bound to the enclosing fx.App,
+	// in the order they were bound.
+	Symbols []BoundSymbol
+
+	// OnStart is the symbol bound as an OnStart callback, if any.
+	OnStart string
+
+	// OnStop is the symbol bound as an OnStop callback, if any.
+	OnStop string
+
+	// Status is the current status of the plugin.
+	Status Status
+
+	// Condition describes the condition that disabled the plugin.  This field
+	// is only set when Status is StatusDisabled.
+	Condition string
+
+	// Original is the path the plugin file was first loaded through.  This field is
+	// only set when Status is StatusDuplicate.
+	Original string
+
+	// Err holds any errors encountered while loading, binding, or running the
+	// plugin's lifecycle callbacks.  Multiple errors are combined with go.uber.org/multierr.
+	Err error
+
+	// LoadTime is when the plugin was loaded.
+	LoadTime time.Time
+
+	// Build describes how the plugin was built, so that the version of each running plugin
+	// can be reported.  This field is nil until the plugin is opened, and remains nil if the
+	// plugin's build information could not be read.
+	Build *BuildInfo
+}
+
+// BuildInfo is the build information embedded in a plugin file, as read by debug/buildinfo.
+type BuildInfo struct {
+	// Path is the import path of the plugin's main package.
+	Path string
+
+	// Module is the path of the module that contains the plugin's main package.
+	Module string
+
+	// Version is the version of that module.  A plugin built from a local source tree
+	// may report "(devel)" or a pseudo-version derived from version control.
+	Version string
+
+	// GoVersion is the version of the Go toolchain that built the plugin.
+	GoVersion string
+}
+
+// readBuildInfo reads the build information from a plugin file.  If the file has
+// none, this function returns nil.
+func readBuildInfo(path string) *BuildInfo {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	return &BuildInfo{
+		Path:      info.Path,
+		Module:    info.Main.Path,
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+}
+
+// registryEntry is the mutable, concurrency-safe state behind a Record.
+type registryEntry struct {
+	// seq orders entries by when their plugins were declared, since dig
+	// delivers value groups in no particular order
+	seq uint64
+
+	lock   sync.Mutex
+	record Record
+}
+
+// entrySequence is the source of each registryEntry's seq.
+var entrySequence uint64
+
+// nextSequence returns the next value of entrySequence.
+func nextSequence() uint64 {
+	return atomic.AddUint64(&entrySequence, 1)
+}
+
+// apply updates this entry's record in response to an event.
+func (re *registryEntry) apply(e Event) {
+	re.lock.Lock()
+	defer re.lock.Unlock()
+
+	switch e := e.(type) {
+	case *Opened:
+		re.record.Status = StatusLoaded
+
+	case *OpenFailed:
+		re.fail(e.Err)
+
+	case *SymbolBound:
+		re.record.Symbols = append(re.record.Symbols, BoundSymbol{
+			Name: e.Name,
+			Kind: e.Kind,
+			Type: e.Type,
+		})
+
+	case *SymbolMissing:
+		if !e.Ignored {
+			re.fail(&MissingSymbolError{Name: e.Name})
+		}
+
+	case *SymbolRejected:
+		re.fail(e.Err)
+
+	case *LifecycleBound:
+		re.record.OnStart = e.OnStart
+		re.record.OnStop = e.OnStop
+
+	case *Skipped:
+		re.record.Status = StatusSkipped
+		if re.record.Err == nil {
+			re.record.Err = e.Err
+		}
+
+	case *Disabled:
+		re.record.Status = StatusDisabled
+		re.record.Condition = e.Condition
+
+	case *Duplicate:
+		re.record.Status = StatusDuplicate
+		re.record.Original = e.Original
+	}
+}
+
+// setBuild records the build information of this entry's plugin.
+func (re *registryEntry) setBuild(b *BuildInfo) {
+	re.lock.Lock()
+	re.record.Build = b
+	re.lock.Unlock()
+}
+
+// fail must be called under the lock.
+func (re *registryEntry) fail(err error) {
+	re.record.Status = StatusFailed
+	re.record.Err = multierr.Append(re.record.Err, err)
+}
+
+// hookDone updates this entry after a lifecycle callback completes.
+func (re *registryEntry) hookDone(success Status, err error) {
+	re.lock.Lock()
+	if err != nil {
+		re.fail(err)
+	} else {
+		re.record.Status = success
+	}
+
+	re.lock.Unlock()
+}
+
+// snapshot returns a copy of this entry's current record.
+func (re *registryEntry) snapshot() Record {
+	re.lock.Lock()
+	defer re.lock.Unlock()
+
+	r := re.record
+	r.Symbols = append([]BoundSymbol(nil), re.record.Symbols...)
+	return r
+}
+
+// trackHook decorates a lifecycle callback so that its outcome is recorded in this
+// loader's registry entry.  If there is no entry, callback is returned as is.
+func (l loader) trackHook(success Status, callback func(context.Context) error) func(context.Context) error {
+	if l.entry == nil || callback == nil {
+		return callback
+	}
+
+	return func(ctx context.Context) error {
+		err := callback(ctx)
+		l.entry.hookDone(success, err)
+		return err
+	}
+}
+
+// provideEntry returns the option that contributes this loader's registry entry to
+// the enclosing fx.App.
+func (l loader) provideEntry() fx.Option {
+	return fx.Provide(
+		fx.Annotated{
+			Group:  registryGroup,
+			Target: func() *registryEntry { return l.entry },
+		},
+	)
+}
+
+// Registry is a queryable collection of the plugins loaded by P and S within
+// an enclosing fx.App.  Use ProvideRegistry to make a *Registry available as a component.
+type Registry struct {
+	entries []*registryEntry
+}
+
+type registryIn struct {
+	fx.In
+
+	Entries []*registryEntry `group:"pluginfx.registry"`
+}
+
+func newRegistry(in registryIn) *Registry {
+	entries := append([]*registryEntry(nil), in.Entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+
+	return &Registry{
+		entries: entries,
+	}
+}
+
+// ProvideRegistry provides a *Registry populated with every plugin loaded by P.Provide
+// and S.Provide within the enclosing fx.App.  This option should be included once.
+//
+//   app := fx.New(
+//     pluginfx.P{ /* ... */ }.Provide(),
+//     pluginfx.S{ /* ... */ }.Provide(),
+//     pluginfx.ProvideRegistry(),
+//     fx.Invoke(
+//       func(r *pluginfx.Registry) {
+//         for _, record := range r.Records() {
+//           // ...
+//         }
+//       },
+//     ),
+//   )
+func ProvideRegistry() fx.Option {
+	return fx.Provide(newRegistry)
+}
+
+// Len returns the number of plugins in this registry.
+func (r *Registry) Len() int {
+	return len(r.entries)
+}
+
+// Records returns a snapshot of every plugin in this registry, in the order the plugins were
+// declared:  the order of P.Provide and S.Provide calls, and within an S, the order of Paths and
+// of the matches for each path.
+func (r *Registry) Records() []Record {
+	records := make([]Record, 0, len(r.entries))
+	for _, e := range r.entries {
+		records = append(records, e.snapshot())
+	}
+
+	return records
+}
+
+// Get returns a snapshot of the plugin loaded from the given path, which may be either
+// the configured path or the expanded path.
+func (r *Registry) Get(path string) (Record, bool) {
+	for _, e := range r.entries {
+		record := e.snapshot()
+		if record.Path == path || record.ExpandedPath == path {
+			return record, true
+		}
+	}
+
+	return Record{}, false
+}
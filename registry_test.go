@@ -0,0 +1,173 @@
+package pluginfx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type RegistrySuite struct {
+	PluginfxSuite
+}
+
+func (suite *RegistrySuite) TestStatus() {
+	suite.Equal("loaded", StatusLoaded.String())
+	suite.Equal("failed", StatusFailed.String())
+	suite.Equal("started", StatusStarted.String())
+	suite.Equal("stopped", StatusStopped.String())
+	suite.Equal("skipped", StatusSkipped.String())
+	suite.Equal("disabled", StatusDisabled.String())
+	suite.Equal("pending", StatusPending.String())
+	suite.Equal("unknown", Status(-1).String())
+}
+
+func (suite *RegistrySuite) TestProvideRegistry() {
+	var (
+		registry *Registry
+
+		app = fxtest.New(
+			suite.T(),
+			P{
+				Name: "sample",
+				Path: "${PWD}/" + samplePath,
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+				Lifecycle: Lifecycle{
+					OnStart: "Initialize",
+					OnStop:  "Shutdown",
+				},
+			}.Provide(),
+			S{
+				Paths: []string{"*.so"},
+			}.Provide(),
+			ProvideRegistry(),
+			fx.Populate(&registry),
+		)
+	)
+
+	suite.Require().NotNil(registry)
+	suite.Equal(2, registry.Len())
+
+	record, ok := registry.Get("${PWD}/" + samplePath)
+	suite.Require().True(ok)
+	suite.Equal("sample", record.Name)
+	suite.NotEqual(record.Path, record.ExpandedPath)
+	suite.Equal(StatusLoaded, record.Status)
+	suite.NoError(record.Err)
+	suite.False(record.LoadTime.IsZero())
+	suite.Equal("Initialize", record.OnStart)
+	suite.Equal("Shutdown", record.OnStop)
+	suite.Require().Len(record.Symbols, 1)
+	suite.Equal("New", record.Symbols[0].Name)
+	suite.Equal(ProvideSymbol, record.Symbols[0].Kind)
+	suite.Require().NotNil(record.Build)
+	suite.Equal("github.com/xmidt-org/pluginfx/sample", record.Build.Path)
+	suite.Equal("github.com/xmidt-org/pluginfx", record.Build.Module)
+	suite.NotEmpty(record.Build.GoVersion)
+
+	record, ok = registry.Get("*.so")
+	suite.Require().True(ok)
+	suite.Equal("*.so", record.Path)
+	suite.Equal(sampleOpenPath, record.ExpandedPath)
+	suite.Empty(record.Symbols)
+
+	suite.Run("NoBuildInfo", func() {
+		suite.Nil(readBuildInfo("nosuch.so"))
+	})
+
+	_, ok = registry.Get("nosuch")
+	suite.False(ok)
+
+	app.RequireStart()
+	record, _ = registry.Get("${PWD}/" + samplePath)
+	suite.Equal(StatusStarted, record.Status)
+
+	app.RequireStop()
+	record, _ = registry.Get("${PWD}/" + samplePath)
+	suite.Equal(StatusStopped, record.Status)
+
+	suite.Len(registry.Records(), 2)
+}
+
+func (suite *RegistrySuite) TestOrder() {
+	// dig delivers value groups in a random order, so try several times
+	for i := 0; i < 5; i++ {
+		var (
+			options  []fx.Option
+			expected []string
+			disabled = Not(EnvPresent("PATH"))
+			registry *Registry
+		)
+
+		for j := 0; j < 4; j++ {
+			path := fmt.Sprintf("p%d.so", j)
+			expected = append(expected, path)
+			options = append(options, P{Path: path, Enabled: disabled}.Provide())
+
+			paths := []string{fmt.Sprintf("s%d-a.so", j), fmt.Sprintf("s%d-b.so", j)}
+			expected = append(expected, paths...)
+			options = append(options, S{Paths: paths, Enabled: disabled}.Provide())
+		}
+
+		app := fxtest.New(
+			suite.T(),
+			append(options, ProvideRegistry(), fx.Populate(&registry))...,
+		)
+
+		var actual []string
+		for _, record := range registry.Records() {
+			actual = append(actual, record.Path)
+		}
+
+		suite.Equal(expected, actual)
+		app.RequireStart()
+		app.RequireStop()
+	}
+}
+
+func (suite *RegistrySuite) TestEntry() {
+	suite.Run("Failures", func() {
+		var (
+			entry registryEntry
+			l     = loader{entry: &entry}
+		)
+
+		l.log(&OpenFailed{Err: errors.New("expected")})
+		l.log(&SymbolMissing{Name: "Ignored", Ignored: true})
+		l.log(&SymbolMissing{Name: "Missing"})
+		l.log(&SymbolRejected{Name: "Rejected", Err: errors.New("expected")})
+
+		record := entry.snapshot()
+		suite.Equal(StatusFailed, record.Status)
+		suite.Error(record.Err)
+		suite.True(IsMissingSymbolError(record.Err))
+	})
+
+	suite.Run("HookError", func() {
+		var (
+			entry registryEntry
+			l     = loader{entry: &entry}
+
+			hook = l.trackHook(StatusStarted, func(context.Context) error {
+				return errors.New("expected")
+			})
+		)
+
+		suite.Error(hook(context.Background()))
+		suite.Equal(StatusFailed, entry.snapshot().Status)
+	})
+
+	suite.Run("NoEntry", func() {
+		suite.Nil(loader{}.trackHook(StatusStarted, nil))
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	suite.Run(t, new(RegistrySuite))
+}
@@ -4,6 +4,23 @@ import "errors"
 
 var Value int = 12
 
+// Config is the configuration delivered to this plugin.
+type Config struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// Settings holds the most recent configuration passed to Configure.
+var Settings Config
+
+func Configure(cfg Config) error {
+	if len(cfg.Name) == 0 {
+		return errors.New("expected sample plugin configure error")
+	}
+
+	Settings = cfg
+	return nil
+}
+
 func New() (float64, error) {
 	return 67.5, nil
 }
@@ -0,0 +1,60 @@
+package pluginfx
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SearchPathEnv is the environment variable that overrides the configured search path
+// of P and S.  Its value is a list of directories separated by os.PathListSeparator,
+// in the same format as PATH or LD_LIBRARY_PATH.
+const SearchPathEnv = "PLUGINFX_PATH"
+
+// searchPath returns the directories that relative plugin paths are resolved against.
+// SearchPathEnv, if defined, takes precedence over the configured directories.  Each
+// directory is expanded with the given Expander.
+func searchPath(e Expander, configured []string) (dirs []string, err error) {
+	if v, ok := os.LookupEnv(SearchPathEnv); ok {
+		configured = filepath.SplitList(v)
+	}
+
+	for _, dir := range configured {
+		if dir, err = expand(e, dir); err != nil {
+			return
+		}
+
+		dirs = append(dirs, dir)
+	}
+
+	return
+}
+
+// absolute returns the absolute form of a path.  If that cannot be determined, path
+// is returned as is.
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// search returns the absolute locations a path may refer to, in order.  An absolute
+// path or a URL is returned as is.  A relative path is joined to each directory in the search
+// path or, if there are no such directories, to the current working directory.
+func search(path string, dirs []string) []string {
+	switch {
+	case filepath.IsAbs(path) || isURL(path):
+		return []string{path}
+
+	case len(dirs) == 0:
+		return []string{absolute(path)}
+	}
+
+	locations := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		locations = append(locations, absolute(filepath.Join(dir, path)))
+	}
+
+	return locations
+}
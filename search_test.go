@@ -0,0 +1,204 @@
+package pluginfx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type SearchSuite struct {
+	PluginfxSuite
+}
+
+// sampleDir returns a temporary directory containing a symlink to the sample plugin.
+func (suite *SearchSuite) sampleDir(name string) string {
+	target, err := filepath.Abs(samplePath)
+	suite.Require().NoError(err)
+
+	dir := suite.T().TempDir()
+	suite.Require().NoError(os.Symlink(target, filepath.Join(dir, name)))
+	return dir
+}
+
+// unsetSearchPath removes SearchPathEnv for the duration of the current test.
+func (suite *SearchSuite) unsetSearchPath() {
+	if v, ok := os.LookupEnv(SearchPathEnv); ok {
+		suite.T().Cleanup(func() { os.Setenv(SearchPathEnv, v) })
+	}
+}
+
+func (suite *SearchSuite) TestSearchPath() {
+	suite.Run("Configured", func() {
+		suite.T().Setenv("PLUGINFX_TEST_DIR", "/opt/plugins")
+
+		dirs, err := searchPath(nil, []string{"$PLUGINFX_TEST_DIR", "/usr/lib"})
+		suite.NoError(err)
+		suite.Equal([]string{"/opt/plugins", "/usr/lib"}, dirs)
+	})
+
+	suite.Run("Env", func() {
+		suite.T().Setenv(SearchPathEnv, "/a"+string(os.PathListSeparator)+"/b")
+
+		dirs, err := searchPath(nil, []string{"/usr/lib"})
+		suite.NoError(err)
+		suite.Equal([]string{"/a", "/b"}, dirs)
+	})
+
+	suite.Run("EmptyEnv", func() {
+		suite.T().Setenv(SearchPathEnv, "")
+
+		dirs, err := searchPath(nil, []string{"/usr/lib"})
+		suite.NoError(err)
+		suite.Empty(dirs)
+	})
+
+	suite.Run("ExpandError", func() {
+
+		_, err := searchPath(ShellExpander{Strict: true}, []string{"$PLUGINFX_TEST_NOSUCH"})
+		suite.ErrorIs(err, ErrUndefinedVariable)
+	})
+}
+
+func (suite *SearchSuite) TestSearch() {
+	cwd, err := os.Getwd()
+	suite.Require().NoError(err)
+
+	suite.Equal([]string{"/abs/plugin.so"}, search("/abs/plugin.so", []string{"/a", "/b"}))
+	suite.Equal([]string{filepath.Join(cwd, "plugin.so")}, search("plugin.so", nil))
+	suite.Equal(
+		[]string{"/a/sub/plugin.so", filepath.Join(cwd, "rel", "sub", "plugin.so")},
+		search("sub/plugin.so", []string{"/a", "rel"}),
+	)
+}
+
+func (suite *SearchSuite) TestP() {
+	suite.Run("Found", func() {
+		var (
+			recorder eventRecorder
+			value    float64
+
+			empty = suite.T().TempDir()
+			dir   = suite.sampleDir("found.so")
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous:  true,
+					Path:       "found.so",
+					SearchPath: []string{empty, dir},
+					Logger:     &recorder,
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(expectedNewValue, value)
+		suite.Require().NotEmpty(recorder.events)
+		suite.Equal(
+			&Selected{
+				Path:       filepath.Join(dir, "found.so"),
+				Candidates: []string{filepath.Join(empty, "found.so"), filepath.Join(dir, "found.so")},
+			},
+			recorder.events[0],
+		)
+	})
+
+	suite.Run("Env", func() {
+		var (
+			value float64
+			dir   = suite.sampleDir("env.so")
+		)
+
+		suite.T().Setenv(SearchPathEnv, dir)
+		app := fxtest.New(
+			suite.T(),
+			P{
+				Anonymous:  true,
+				Path:       "env.so",
+				SearchPath: []string{suite.T().TempDir()},
+				Symbols: Symbols{
+					Names: []interface{}{"New"},
+				},
+			}.Provide(),
+			fx.Populate(&value),
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(expectedNewValue, value)
+	})
+
+	suite.Run("NotFound", func() {
+		first, second := suite.T().TempDir(), suite.T().TempDir()
+		app := fx.New(
+			P{
+				Anonymous:  true,
+				Path:       "nosuch.so",
+				SearchPath: []string{first, second},
+			}.Provide(),
+		)
+
+		var ce *CandidateError
+		suite.Require().True(errors.As(app.Err(), &ce))
+		suite.Equal("nosuch.so", ce.Path)
+		suite.Equal([]string{filepath.Join(first, "nosuch.so"), filepath.Join(second, "nosuch.so")}, ce.Candidates)
+	})
+
+	suite.Run("AbsoluteOpenError", func() {
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      "nosuch.so",
+			}.Provide(),
+		)
+
+		var oe *OpenError
+		suite.Require().True(errors.As(app.Err(), &oe))
+		suite.Equal(absolute("nosuch.so"), oe.Path)
+		suite.True(filepath.IsAbs(oe.Path))
+	})
+}
+
+func (suite *SearchSuite) TestS() {
+	var (
+		values []float64
+		dir    = suite.sampleDir("globbed.so")
+
+		app = fxtest.New(
+			suite.T(),
+			S{
+				Paths:      []string{"glob*.so", "nomatch*.so"},
+				SearchPath: []string{suite.T().TempDir(), dir},
+				Symbols: Symbols{
+					Names: []interface{}{Annotated{Group: "values", Target: "New"}},
+				},
+			}.Provide(),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					Values []float64 `group:"values"`
+				}) {
+					values = in.Values
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Equal([]float64{expectedNewValue}, values)
+}
+
+func TestSearch(t *testing.T) {
+	suite.Run(t, new(SearchSuite))
+}
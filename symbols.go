@@ -10,6 +10,56 @@ import (
 // errType is the "cached" reflection type for error.
 var errType = reflect.TypeOf((*error)(nil)).Elem()
 
+// SymbolKind describes how a plugin symbol is bound to an enclosing fx.App.
+type SymbolKind int
+
+const (
+	// ProvideSymbol indicates a constructor passed to fx.Provide.
+	ProvideSymbol SymbolKind = iota
+
+	// InvokeSymbol indicates a function passed to fx.Invoke.
+	InvokeSymbol
+
+	// TargetSymbol indicates a constructor passed to fx.Provide via fx.Annotated.
+	TargetSymbol
+
+	// OnStartSymbol indicates a lifecycle callback bound as an OnStart hook.
+	OnStartSymbol
+
+	// OnStopSymbol indicates a lifecycle callback bound as an OnStop hook.
+	OnStopSymbol
+
+	// CheckSymbol indicates a symbol that is verified against its contract
+	// but not otherwise bound.
+	CheckSymbol
+)
+
+// String returns a human-readable label for this kind.
+func (sk SymbolKind) String() string {
+	switch sk {
+	case ProvideSymbol:
+		return "PROVIDE"
+
+	case InvokeSymbol:
+		return "INVOKE"
+
+	case TargetSymbol:
+		return "TARGET"
+
+	case OnStartSymbol:
+		return "ONSTART"
+
+	case OnStopSymbol:
+		return "ONSTOP"
+
+	case CheckSymbol:
+		return "CHECK"
+
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // InvalidTargetError indicates that a type was not valid for the
 // fx.Annotated.Target field.  This is more restrictive than a constructor.
 // Targets must return exactly (1) non-error object, with an optional error.
@@ -60,94 +110,152 @@ type Symbols struct {
 	// If this field is true, then missing symbols are silently ignored.  Otherwise,
 	// a missing symbol will shortcircuit application startup with an error.
 	IgnoreMissing bool
+
+	// Contracts optionally declares the type the host expects for each named symbol.
+	// Each value must be one of:
+	//
+	//   - a function, typically a typed nil such as (func(Config) (Widget, error))(nil),
+	//     in which case the symbol must have exactly that function type
+	//   - a pointer to an interface, such as (*io.Reader)(nil), in which case the symbol
+	//     must be a variable that implements that interface
+	//
+	// A symbol that does not match its contract results in a *ContractError that shows
+	// the expected and actual types.  Contracts for symbols not in Names are still verified,
+	// as CheckSymbol steps, but those symbols are not bound to the enclosing fx.App.
+	Contracts map[string]interface{}
+
+	// Policy optionally restricts the components that constructors in Names may provide.
+	// Any violation results in a *PolicyError, and the constructor is not passed to fx.Provide.
+	// If unset, constructors may provide any component.
+	Policy *Policy
+
+	// Logger is the optional sink for the events that occur while Load binds each symbol,
+	// each with an empty Path.  This field is ignored when these Symbols are part of a P or S,
+	// which report to their own Logger.
+	Logger Logger
+
+	// Metrics is the optional sink for measurements of each call to the constructors and
+	// invoke functions bound by Load, with an empty Labels.Path.  This field is ignored when
+	// these Symbols are part of a P or S, which report to their own Metrics.
+	Metrics Metrics
 }
 
-func (s Symbols) lookupFunc(p Plugin, n string, o []fx.Option) (reflect.Value, []fx.Option) {
+// lookupFunc looks up a symbol that must be a function, verifying it against
+// its contract if one was declared.
+func (s Symbols) lookupFunc(p Plugin, n string) (reflect.Value, error) {
 	symbol, err := Lookup(p, n)
-	if IsMissingSymbolError(err) {
-		if !s.IgnoreMissing {
-			o = append(o, fx.Error(err))
-		}
+	if err != nil {
+		return reflect.Value{}, err
+	}
 
-		return reflect.Value{}, o
+	if err := s.checkContract(n, symbol); err != nil {
+		return reflect.Value{}, err
 	}
 
 	sv := reflect.ValueOf(symbol)
 	if sv.Kind() != reflect.Func {
-		return reflect.Value{},
-			append(o, fx.Error(
-				fmt.Errorf("Symbol %s is not a function", n),
-			))
+		return reflect.Value{}, fmt.Errorf("Symbol %s is not a function", n)
 	}
 
-	return sv, o
+	return sv, nil
 }
 
-func (s Symbols) constructorOrInvoke(v reflect.Value, o []fx.Option) []fx.Option {
-	vt := v.Type()
-	for i := 0; i < vt.NumOut(); i++ {
-		if vt.Out(i) != errType {
+// constructorOrInvokeKind determines whether a function type is a constructor
+// or an invoke function.  Any function that returns at least (1) non-error
+// value is a constructor.
+func constructorOrInvokeKind(ft reflect.Type) SymbolKind {
+	for i := 0; i < ft.NumOut(); i++ {
+		if ft.Out(i) != errType {
 			// any non-error type means it's a constructor
-			return append(o, fx.Provide(v.Interface()))
+			return ProvideSymbol
 		}
 	}
 
-	return append(o, fx.Invoke(v.Interface()))
+	return InvokeSymbol
 }
 
-func (s Symbols) target(a Annotated, v reflect.Value, o []fx.Option) []fx.Option {
-	vt := v.Type()
+// isValidTarget tests if a function type is legal for fx.Annotated.Target.
+func isValidTarget(ft reflect.Type) bool {
 	switch {
-	case vt.NumOut() < 1 || vt.NumOut() > 2:
-		fallthrough
-
-	case vt.NumOut() == 1 && vt.Out(0) == errType:
-		fallthrough
-
-	case vt.NumOut() == 2 && vt.Out(0) == errType && vt.Out(1) == errType:
-		fallthrough
-
-	case vt.NumOut() == 2 && vt.Out(0) != errType && vt.Out(1) != errType:
-		return append(o, fx.Error(
-			&InvalidTargetError{
-				Name: a.Target,
-				Type: vt,
-			},
-		))
-	}
+	case ft.NumOut() < 1 || ft.NumOut() > 2:
+		return false
+
+	case ft.NumOut() == 1 && ft.Out(0) == errType:
+		return false
+
+	case ft.NumOut() == 2 && ft.Out(0) == errType && ft.Out(1) == errType:
+		return false
+
+	case ft.NumOut() == 2 && ft.Out(0) != errType && ft.Out(1) != errType:
+		return false
 
-	return append(o, fx.Provide(
-		fx.Annotated{
-			Name:   a.Name,
-			Group:  a.Group,
-			Target: v.Interface(),
-		},
-	))
+	default:
+		return true
+	}
 }
 
-func (s Symbols) Load(p Plugin) fx.Option {
-	options := make([]fx.Option, 0, len(s.Names))
-	for _, n := range s.Names {
-		var v reflect.Value
-		switch name := n.(type) {
-		case string:
-			v, options = s.lookupFunc(p, name, options)
-			if v.IsValid() {
-				options = s.constructorOrInvoke(v, options)
-			}
+// step resolves a single element of Names against a plugin.
+func (s Symbols) step(p Plugin, n interface{}) (st Step) {
+	switch name := n.(type) {
+	case string:
+		st.Symbol = name
+		st.value, st.Err = s.lookupFunc(p, name)
+		if st.Err == nil {
+			st.setType(st.value.Type())
+			st.Kind = constructorOrInvokeKind(st.Type)
+		}
 
-		case Annotated:
-			v, options = s.lookupFunc(p, name.Target, options)
-			if v.IsValid() {
-				options = s.target(name, v, options)
+	case Annotated:
+		st.Symbol = name.Target
+		st.Kind = TargetSymbol
+		st.Name = name.Name
+		st.Group = name.Group
+		st.value, st.Err = s.lookupFunc(p, name.Target)
+		if st.Err == nil {
+			st.setType(st.value.Type())
+			if !isValidTarget(st.Type) {
+				st.Err = &InvalidTargetError{
+					Name: name.Target,
+					Type: st.Type,
+				}
 			}
-
-		default:
-			options = append(options, fx.Error(
-				fmt.Errorf("%T is not valid for Symbols.Names", n),
-			))
 		}
+
+	default:
+		st.Symbol = fmt.Sprint(n)
+		st.Err = fmt.Errorf("%T is not valid for Symbols.Names", n)
+	}
+
+	if st.Err == nil && s.Policy != nil && st.Kind != InvokeSymbol {
+		st.Err = s.Policy.check(st)
 	}
 
-	return fx.Options(options...)
+	st.Ignored = s.IgnoreMissing && IsMissingSymbolError(st.Err)
+	return
+}
+
+// Plan resolves each of the configured symbols against the given plugin, without
+// binding anything to an fx.App.
+func (s Symbols) Plan(p Plugin) *Plan {
+	plan := &Plan{
+		Steps: make([]Step, 0, len(s.Names)),
+	}
+
+	for _, n := range s.Names {
+		plan.Steps = append(plan.Steps, s.step(p, n))
+	}
+
+	for _, n := range s.contractOnly() {
+		plan.Steps = append(plan.Steps, s.checkStep(p, n))
+	}
+
+	return plan
+}
+
+// Load looks up each of the configured symbols in the given plugin and
+// returns the options that bind those symbols to an enclosing fx.App.
+// This method is equivalent to s.Plan(p).Options(), except that events are
+// logged to Logger and calls are measured by Metrics.
+func (s Symbols) Load(p Plugin) fx.Option {
+	return s.Plan(p).options(loader{logger: s.Logger, metrics: s.Metrics})
 }
@@ -0,0 +1,120 @@
+package pluginfx
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strings"
+)
+
+const (
+	// GOOSPlaceholder is replaced by Platform.GOOS in a path template.
+	GOOSPlaceholder = "{goos}"
+
+	// GOARCHPlaceholder is replaced by Platform.GOARCH in a path template.
+	GOARCHPlaceholder = "{goarch}"
+
+	// GoVersionPlaceholder is replaced by Platform.GoVersion in a path template.  If the
+	// exact version has a patch level, e.g. go1.20.4, the major.minor version, e.g. go1.20,
+	// is used as a fallback candidate.
+	GoVersionPlaceholder = "{goversion}"
+)
+
+// Platform describes the environment a plugin must have been built for.
+type Platform struct {
+	// GOOS is the operating system, as in runtime.GOOS.
+	GOOS string
+
+	// GOARCH is the architecture, as in runtime.GOARCH.
+	GOARCH string
+
+	// GoVersion is the Go toolchain version, as in runtime.Version().
+	GoVersion string
+}
+
+// HostPlatform returns the Platform of the running process.  Plugins must be built
+// for this platform in order to be opened.
+func HostPlatform() Platform {
+	return Platform{
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// minorVersion returns the major.minor prefix of a Go version such as go1.20.4.  If the
+// version has no patch level, or isn't a release version, the empty string is returned.
+func minorVersion(v string) string {
+	if !strings.HasPrefix(v, "go") {
+		return ""
+	}
+
+	dots := 0
+	for i := 2; i < len(v); i++ {
+		switch {
+		case v[i] == '.':
+			dots++
+			if dots == 2 {
+				return v[:i]
+			}
+
+		case v[i] < '0' || v[i] > '9':
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// Render replaces the placeholders in a path template, returning each candidate path in
+// order of preference.  A path with no placeholders is returned as the only candidate.
+func (pl Platform) Render(path string) []string {
+	path = strings.NewReplacer(
+		GOOSPlaceholder, pl.GOOS,
+		GOARCHPlaceholder, pl.GOARCH,
+	).Replace(path)
+
+	if !strings.Contains(path, GoVersionPlaceholder) {
+		return []string{path}
+	}
+
+	candidates := []string{strings.ReplaceAll(path, GoVersionPlaceholder, pl.GoVersion)}
+	if minor := minorVersion(pl.GoVersion); len(minor) > 0 {
+		candidates = append(candidates, strings.ReplaceAll(path, GoVersionPlaceholder, minor))
+	}
+
+	return candidates
+}
+
+// CandidateError indicates that none of the candidate files for a plugin exist.
+type CandidateError struct {
+	// Path is the configured plugin path.
+	Path string
+
+	// Candidates are the files that were tried, in order.
+	Candidates []string
+}
+
+// Unwrap returns os.ErrNotExist, so that errors.Is(err, fs.ErrNotExist) holds.
+func (ce *CandidateError) Unwrap() error {
+	return os.ErrNotExist
+}
+
+func (ce *CandidateError) Error() string {
+	return fmt.Sprintf("No plugin file found for %s; tried %s", ce.Path, strings.Join(ce.Candidates, ", "))
+}
+
+// selectCandidate returns the first candidate file that exists, according to stat.
+func selectCandidate(path string, candidates []string, stat func(string) (fs.FileInfo, error)) (string, error) {
+	for _, c := range candidates {
+		if _, err := stat(c); err == nil {
+			return c, nil
+		}
+	}
+
+	return "", &CandidateError{
+		Path:       path,
+		Candidates: candidates,
+	}
+}
@@ -0,0 +1,210 @@
+package pluginfx
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type TemplateSuite struct {
+	PluginfxSuite
+}
+
+// linkSample creates a symlink to the sample plugin within a temporary directory.
+// A symlink is used because the Go runtime refuses to load a copy of a plugin
+// that is already loaded.
+func (suite *TemplateSuite) linkSample(name string) (dir, path string) {
+	target, err := filepath.Abs(samplePath)
+	suite.Require().NoError(err)
+
+	dir = suite.T().TempDir()
+	path = filepath.Join(dir, name)
+	suite.Require().NoError(os.Symlink(target, path))
+	return
+}
+
+func (suite *TemplateSuite) TestMinorVersion() {
+	testCases := []struct {
+		version  string
+		expected string
+	}{
+		{version: "go1.20.4", expected: "go1.20"},
+		{version: "go1.20", expected: ""},
+		{version: "go1.21rc2", expected: ""},
+		{version: "devel go1.22-abcdef", expected: ""},
+		{version: "", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		suite.Equal(testCase.expected, minorVersion(testCase.version), testCase.version)
+	}
+}
+
+func (suite *TemplateSuite) TestRender() {
+	pl := Platform{
+		GOOS:      "linux",
+		GOARCH:    "arm64",
+		GoVersion: "go1.20.4",
+	}
+
+	suite.Equal([]string{"/plugins/auth.so"}, pl.Render("/plugins/auth.so"))
+	suite.Equal([]string{"/plugins/linux/arm64/auth.so"}, pl.Render("/plugins/{goos}/{goarch}/auth.so"))
+	suite.Equal(
+		[]string{"/plugins/auth-linux-go1.20.4.so", "/plugins/auth-linux-go1.20.so"},
+		pl.Render("/plugins/auth-{goos}-{goversion}.so"),
+	)
+
+	pl.GoVersion = "go1.21"
+	suite.Equal([]string{"/plugins/go1.21/auth.so"}, pl.Render("/plugins/{goversion}/auth.so"))
+}
+
+func (suite *TemplateSuite) TestHostPlatform() {
+	pl := HostPlatform()
+	suite.Equal(runtime.GOOS, pl.GOOS)
+	suite.Equal(runtime.GOARCH, pl.GOARCH)
+	suite.Equal(runtime.Version(), pl.GoVersion)
+}
+
+func (suite *TemplateSuite) TestP() {
+	suite.Run("Template", func() {
+		var (
+			recorder eventRecorder
+			value    float64
+
+			dir, path = suite.linkSample("sample-" + runtime.GOOS + "-" + runtime.GOARCH + ".so")
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Anonymous: true,
+					Path:      filepath.Join(dir, "sample-{goos}-{goarch}.so"),
+					Template:  true,
+					Logger:    &recorder,
+					Symbols: Symbols{
+						Names: []interface{}{"New"},
+					},
+				}.Provide(),
+				fx.Populate(&value),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal(expectedNewValue, value)
+		suite.Require().NotEmpty(recorder.events)
+		suite.Equal(&Selected{Path: path, Candidates: []string{path}}, recorder.events[0])
+	})
+
+	suite.Run("Fallback", func() {
+		var (
+			recorder eventRecorder
+			registry *Registry
+
+			dir, path = suite.linkSample("sample.so")
+			missing   = filepath.Join(dir, "sample-{goos}-{goversion}.so")
+
+			app = fxtest.New(
+				suite.T(),
+				P{
+					Path:      missing,
+					Template:  true,
+					Fallbacks: []string{path},
+					Logger:    &recorder,
+				}.Provide(),
+				ProvideRegistry(),
+				fx.Populate(&registry),
+			)
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+
+		suite.Require().NotEmpty(recorder.events)
+		selected, ok := recorder.events[0].(*Selected)
+		suite.Require().True(ok)
+		suite.Equal(path, selected.Path)
+		suite.Equal(HostPlatform().Render(missing), selected.Candidates[:len(selected.Candidates)-1])
+		suite.Equal(path, selected.Candidates[len(selected.Candidates)-1])
+
+		record, ok := registry.Get(missing)
+		suite.Require().True(ok)
+		suite.Equal(path, record.ExpandedPath)
+		suite.Equal(StatusLoaded, record.Status)
+	})
+
+	suite.Run("NoCandidates", func() {
+		dir := suite.T().TempDir()
+		app := fx.New(
+			P{
+				Anonymous: true,
+				Path:      filepath.Join(dir, "sample-{goos}.so"),
+				Template:  true,
+				Fallbacks: []string{filepath.Join(dir, "sample.so")},
+			}.Provide(),
+		)
+
+		err := app.Err()
+		suite.Require().Error(err)
+		suite.ErrorIs(err, fs.ErrNotExist)
+
+		var ce *CandidateError
+		suite.Require().True(errors.As(err, &ce))
+		suite.Equal(filepath.Join(dir, "sample-{goos}.so"), ce.Path)
+		suite.Equal(
+			[]string{filepath.Join(dir, "sample-"+runtime.GOOS+".so"), filepath.Join(dir, "sample.so")},
+			ce.Candidates,
+		)
+
+		suite.Contains(ce.Error(), filepath.Join(dir, "sample-"+runtime.GOOS+".so"))
+	})
+}
+
+func (suite *TemplateSuite) TestS() {
+	var (
+		recorder eventRecorder
+		values   []float64
+
+		dir, path = suite.linkSample("sample-" + runtime.GOOS + ".so")
+
+		app = fxtest.New(
+			suite.T(),
+			S{
+				Paths:    []string{filepath.Join(dir, "*-{goos}.so")},
+				Template: true,
+				Logger:   &recorder,
+				Symbols: Symbols{
+					Names: []interface{}{Annotated{Group: "values", Target: "New"}},
+				},
+			}.Provide(),
+			fx.Invoke(
+				func(in struct {
+					fx.In
+					Values []float64 `group:"values"`
+				}) {
+					values = in.Values
+				},
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Equal([]float64{expectedNewValue}, values)
+
+	suite.Require().NotEmpty(recorder.events)
+	selected, ok := recorder.events[0].(*Selected)
+	suite.Require().True(ok)
+	suite.Equal(filepath.Join(dir, "*-"+runtime.GOOS+".so"), selected.Path)
+	suite.NotEqual(path, selected.Path)
+}
+
+func TestTemplate(t *testing.T) {
+	suite.Run(t, new(TemplateSuite))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// BlockEnv names the file whose existence lets this plugin's init function finish.
+const BlockEnv = "PLUGINFX_TEST_BLOCK_FILE"
+
+// init blocks until the file named by BlockEnv exists, so that tests can verify how a
+// plugin that hangs while opening is handled.  It gives up after a while regardless.
+func init() {
+	file := os.Getenv(BlockEnv)
+	for start := time.Now(); len(file) > 0 && time.Since(start) < 30*time.Second; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(file); err == nil {
+			return
+		}
+	}
+}
+
+// Name identifies this plugin.
+func Name() string {
+	return "blocking"
+}
+
+func main() {}
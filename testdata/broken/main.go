@@ -0,0 +1,8 @@
+package main
+
+// Name does not compile, so that tests can verify how build failures are reported.
+func Name() string {
+	return undefined
+}
+
+func main() {}
@@ -0,0 +1,8 @@
+package main
+
+// Name identifies this plugin, which is built from source by tests.
+func Name() string {
+	return "built"
+}
+
+func main() {}
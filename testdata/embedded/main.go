@@ -0,0 +1,9 @@
+package main
+
+// Name identifies this plugin.  It is used to verify plugins loaded from an fs.FS.
+func Name() string {
+	return "embedded"
+}
+
+func main() {
+}
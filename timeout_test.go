@@ -0,0 +1,204 @@
+package pluginfx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
+)
+
+const (
+	blockingSource = "testdata/blocking"
+	blockEnv       = "PLUGINFX_TEST_BLOCK_FILE"
+)
+
+type TimeoutSuite struct {
+	PluginfxSuite
+}
+
+func (suite *TimeoutSuite) timeoutError(expectedPath string, err error) *OpenError {
+	oe := suite.openError(expectedPath, err)
+	suite.Equal(CauseTimeout, oe.Cause)
+	suite.NotEmpty(oe.Remediation())
+	return oe
+}
+
+func (suite *TimeoutSuite) TestOpenContext() {
+	suite.Run("Background", func() {
+		suite.openSuccess(OpenContext(context.Background(), samplePath))
+	})
+
+	suite.Run("Deadline", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		suite.openSuccess(OpenContext(ctx, samplePath))
+	})
+
+	suite.Run("Canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		p, err := OpenContext(ctx, samplePath)
+		suite.Nil(p)
+		suite.timeoutError(samplePath, err)
+		suite.ErrorIs(err, context.Canceled)
+	})
+
+	suite.Run("OpenError", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		_, err := OpenContext(ctx, "nosuch.so")
+		oe := suite.openError("nosuch.so", err)
+		suite.Equal(CauseNotFound, oe.Cause)
+	})
+}
+
+// TestBlocking uses a plugin whose init function blocks until a file exists.  A plugin can only
+// be loaded once, so every case that needs it to block runs before it is released.
+func (suite *TimeoutSuite) TestBlocking() {
+	var (
+		dir     = suite.T().TempDir()
+		release = filepath.Join(dir, "release")
+	)
+
+	built, err := newBuilder(dir).build(blockingSource)
+	suite.Require().NoError(err)
+
+	path := built.Path
+	suite.T().Setenv(blockEnv, release)
+
+	suite.Run("OpenContext", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		p, err := OpenContext(ctx, path)
+		suite.Nil(p)
+		suite.timeoutError(path, err)
+		suite.ErrorIs(err, context.DeadlineExceeded)
+		suite.Less(time.Since(start), 10*time.Second)
+	})
+
+	suite.Run("P", func() {
+		var recorder eventRecorder
+		app := fx.New(
+			P{
+				Anonymous:   true,
+				Path:        path,
+				OpenTimeout: 100 * time.Millisecond,
+				Logger:      &recorder,
+			}.Provide(),
+		)
+
+		suite.timeoutError(path, app.Err())
+		suite.Require().NotEmpty(recorder.events)
+		suite.IsType((*OpenFailed)(nil), recorder.events[len(recorder.events)-1])
+	})
+
+	suite.Run("S", func() {
+		// the blocked plugin uses up the whole deadline, so the plugin after it times out as well
+		app := fx.New(
+			S{
+				Paths:       []string{path, samplePath},
+				OpenTimeout: 200 * time.Millisecond,
+			}.Provide(),
+		)
+
+		errs := multierr.Errors(app.Err())
+		suite.Require().Len(errs, 2)
+		suite.timeoutError(path, errs[0])
+		suite.timeoutError(sampleOpenPath, errs[1])
+	})
+
+	suite.Run("Alias", func() {
+		// an alias of a blocked plugin waits on the same load, so it must time out too
+		var (
+			link    = filepath.Join(dir, "alias.so")
+			tracker = Tracker{Policy: DuplicateAlias}
+		)
+
+		suite.Require().NoError(os.Symlink(path, link))
+		canonical, err := filepath.EvalSymlinks(path)
+		suite.Require().NoError(err)
+
+		start := time.Now()
+		app := fx.New(
+			P{
+				Anonymous:   true,
+				Path:        path,
+				OpenTimeout: 100 * time.Millisecond,
+				Tracker:     &tracker,
+			}.Provide(),
+			P{
+				Anonymous:   true,
+				Path:        link,
+				OpenTimeout: 100 * time.Millisecond,
+				Tracker:     &tracker,
+			}.Provide(),
+		)
+
+		errs := multierr.Errors(app.Err())
+		suite.Require().Len(errs, 2)
+		suite.timeoutError(path, errs[0])
+		suite.timeoutError(canonical, errs[1])
+		suite.Less(time.Since(start), 10*time.Second)
+	})
+
+	suite.Require().NoError(os.WriteFile(release, nil, 0600))
+	suite.Run("Released", func() {
+		var name string
+		app := fxtest.New(
+			suite.T(),
+			P{
+				Anonymous:   true,
+				Path:        path,
+				OpenTimeout: time.Minute,
+				Symbols: Symbols{
+					Names: []interface{}{"Name"},
+				},
+			}.Provide(),
+			fx.Populate(&name),
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+		suite.Equal("blocking", name)
+	})
+}
+
+func (suite *TimeoutSuite) TestS() {
+	var plugins []Plugin
+	app := fxtest.New(
+		suite.T(),
+		S{
+			Group:       "plugins",
+			Paths:       []string{samplePath},
+			OpenTimeout: time.Minute,
+		}.Provide(),
+		fx.Invoke(
+			func(in struct {
+				fx.In
+				Plugins []Plugin `group:"plugins"`
+			}) {
+				plugins = in.Plugins
+			},
+		),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Len(plugins, 1)
+	suite.False(errors.Is(app.Err(), context.DeadlineExceeded))
+}
+
+func TestTimeout(t *testing.T) {
+	suite.Run(t, new(TimeoutSuite))
+}